@@ -2,7 +2,7 @@ package alg_hmac
 
 import (
 	"context"
-	"errors"
+	"fmt"
 
 	"github.com/common-fate/httpsig/verifier"
 )
@@ -39,12 +39,12 @@ var _ verifier.KeyDirectory = &multiHMACKeyDirectory{}
 
 func (d multiHMACKeyDirectory) GetKey(ctx context.Context, kid string, alg string) (verifier.Algorithm, error) {
 	if alg != HMAC_SHA256 {
-		return nil, errors.New("unsupported algorithm for directory")
+		return nil, fmt.Errorf("%w: unsupported algorithm %q for directory", verifier.ErrAlgorithmMismatch, alg)
 	}
 
 	hmac, ok := d.keys[kid]
 	if !ok {
-		return nil, errors.New("key not found")
+		return nil, fmt.Errorf("%w: %q", verifier.ErrKeyNotFound, kid)
 	}
 	return &hmac, nil
 }
@@ -0,0 +1,49 @@
+package alg_hmac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+func TestMultiHMACKeyDirectory_GetKey(t *testing.T) {
+	dir := NewMultiHMACKeyDirectory(map[string]HMAC{
+		"key1": *NewHMAC([]byte("secret")),
+	})
+
+	testcases := []struct {
+		name    string
+		kid     string
+		alg     string
+		wantErr error
+	}{
+		{
+			name: "ok",
+			kid:  "key1",
+			alg:  HMAC_SHA256,
+		},
+		{
+			name:    "key not found",
+			kid:     "key2",
+			alg:     HMAC_SHA256,
+			wantErr: verifier.ErrKeyNotFound,
+		},
+		{
+			name:    "unsupported algorithm",
+			kid:     "key1",
+			alg:     "ecdsa-p256-sha256",
+			wantErr: verifier.ErrAlgorithmMismatch,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := dir.GetKey(context.Background(), tc.kid, tc.alg)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("GetKey() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
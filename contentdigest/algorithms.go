@@ -0,0 +1,137 @@
+package contentdigest
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dunglas/httpsfv"
+)
+
+// Algorithms are the supported digest algorithms, from strongest to weakest.
+//
+// Deprecated algorithms in the Hash Algorithms for HTTP Digest Fields
+// registry, such as 'md5' and 'sha', are not supported.
+// See: https://www.rfc-editor.org/rfc/rfc9530.html#section-5
+var Algorithms = []Digester{SHA512, SHA384, SHA256}
+
+// Lookup returns the supported digester for an algorithm key, such as 'sha-256'.
+func Lookup(key string) (Digester, bool) {
+	for _, d := range Algorithms {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return Digester{}, false
+}
+
+// strength returns the rank of the algorithm in Algorithms,
+// where a lower value is stronger. Unsupported algorithms return -1.
+func strength(key string) int {
+	for i, d := range Algorithms {
+		if d.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// WithAlgorithm returns a copy of the digester using a different algorithm,
+// keeping the MaxBytes, SpillThreshold and TempDir settings.
+//
+// The returned bool is false if the algorithm is not supported.
+func (d Digester) WithAlgorithm(key string) (Digester, bool) {
+	alg, ok := Lookup(key)
+	if !ok {
+		return Digester{}, false
+	}
+
+	d.Key = alg.Key
+	d.HashFunc = alg.HashFunc
+	return d, true
+}
+
+// Parse parses the values of a Content-Digest or Repr-Digest field,
+// which may contain digests for multiple algorithms.
+//
+// The returned map contains the digest for each algorithm key,
+// including any algorithms which are not supported.
+func Parse(values []string) (map[string][]byte, error) {
+	if len(values) == 0 {
+		return nil, errors.New("digest field was empty")
+	}
+
+	dict, err := httpsfv.UnmarshalDictionary(values)
+	if err != nil {
+		return nil, fmt.Errorf("parsing digest field: %w", err)
+	}
+
+	digests := make(map[string][]byte, len(dict.Names()))
+
+	for _, key := range dict.Names() {
+		member, _ := dict.Get(key)
+
+		item, ok := member.(httpsfv.Item)
+		if !ok {
+			return nil, fmt.Errorf("could not cast %q digest to a httpsfv.Item", key)
+		}
+
+		digest, ok := item.Value.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("could not cast %q digest to bytes", key)
+		}
+
+		digests[key] = digest
+	}
+
+	return digests, nil
+}
+
+// Negotiate returns a digester for the strongest supported algorithm in the
+// declared Content-Digest or Repr-Digest field values, so that the declared
+// digest can be verified. The MaxBytes, SpillThreshold and TempDir settings
+// of d are kept.
+//
+// Only algorithms which are at least as strong as the algorithm of d are
+// accepted, so that the sender can't downgrade the digest algorithm. If d
+// uses an algorithm which is not in Algorithms, only that algorithm is accepted.
+// If d does not have an algorithm, any supported algorithm is accepted.
+//
+// An error is returned if none of the declared algorithms are acceptable.
+func (d Digester) Negotiate(declared []string) (Digester, error) {
+	digests, err := Parse(declared)
+	if err != nil {
+		return Digester{}, err
+	}
+
+	minimum := strength(d.Key)
+	switch {
+	case d.Key == "":
+		minimum = len(Algorithms) - 1
+	case minimum == -1:
+		if _, ok := digests[d.Key]; ok {
+			return d, nil
+		}
+		return Digester{}, fmt.Errorf("digest field did not contain the %q algorithm", d.Key)
+	}
+
+	best := ""
+	for key := range digests {
+		s := strength(key)
+		if s == -1 || s > minimum {
+			continue
+		}
+		if best == "" || s < strength(best) {
+			best = key
+		}
+	}
+
+	if best == "" && d.Key == "" {
+		return Digester{}, errors.New("digest field did not contain a supported algorithm")
+	}
+	if best == "" {
+		return Digester{}, fmt.Errorf("digest field did not contain a supported algorithm at least as strong as %q", d.Key)
+	}
+
+	negotiated, _ := d.WithAlgorithm(best)
+	return negotiated, nil
+}
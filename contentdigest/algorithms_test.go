@@ -0,0 +1,120 @@
+package contentdigest
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	got, err := Parse([]string{
+		`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`,
+		`md5=:Sd/dVLAcvNLSq16eXua5uQ==:`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"md5", "sha-256"}
+	var keys []string
+	for k := range got {
+		keys = append(keys, k)
+	}
+
+	if diff := cmp.Diff(want, keys, cmpSortStrings); diff != "" {
+		t.Fatalf("Parse() keys mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = Parse([]string{`sha-256=1`})
+	if err == nil {
+		t.Fatal("expected an error parsing a digest which is not a byte sequence")
+	}
+}
+
+func TestDigester_Negotiate(t *testing.T) {
+	type testcase struct {
+		name     string
+		digester Digester
+		declared []string
+		wantKey  string
+		wantErr  bool
+	}
+	testcases := []testcase{
+		{
+			name:     "single",
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			wantKey:  "sha-256",
+		},
+		{
+			name: "strongest",
+			declared: []string{
+				`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:`,
+			},
+			wantKey: "sha-512",
+		},
+		{
+			name: "unsupported_algorithms_are_ignored",
+			declared: []string{
+				`md5=:Sd/dVLAcvNLSq16eXua5uQ==:, sha-384=:J18bw2UtvxqNrirFegHaLA9KXQ7md8zRDoK81RVOwjrn6ke9OXAumdM9r3ccom4a:`,
+			},
+			wantKey: "sha-384",
+		},
+		{
+			name:     "weaker_than_digester",
+			digester: SHA512,
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			wantErr:  true,
+		},
+		{
+			name:     "weaker_algorithms_are_ignored",
+			digester: SHA384,
+			declared: []string{
+				`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, sha-384=:J18bw2UtvxqNrirFegHaLA9KXQ7md8zRDoK81RVOwjrn6ke9OXAumdM9r3ccom4a:`,
+			},
+			wantKey: "sha-384",
+		},
+		{
+			name:     "custom_digester",
+			digester: Digester{Key: "custom", HashFunc: SHA256.HashFunc},
+			declared: []string{`sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:, custom=:AA==:`},
+			wantKey:  "custom",
+		},
+		{
+			name:     "no_supported_algorithms",
+			declared: []string{`md5=:Sd/dVLAcvNLSq16eXua5uQ==:`},
+			wantErr:  true,
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := tc.digester
+			if d.Key == "" {
+				d = SHA256
+			}
+			d.MaxBytes = 10
+			d.SpillThreshold = 5
+
+			got, err := d.Negotiate(tc.declared)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("wantErr = %v, err = %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.Key != tc.wantKey {
+				t.Fatalf("Key = %s, want %s", got.Key, tc.wantKey)
+			}
+
+			// the settings of the digester are kept.
+			if got.MaxBytes != 10 || got.SpillThreshold != 5 {
+				t.Fatalf("settings were not kept: MaxBytes = %d, SpillThreshold = %d", got.MaxBytes, got.SpillThreshold)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package contentdigest
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"crypto/sha512"
 	"errors"
@@ -23,14 +22,28 @@ type Digester struct {
 
 	// MaxBytes is the limit of bytes to read to prevent DOS attacks.
 	MaxBytes int64
+
+	// SpillThreshold, if non-zero, is the number of bytes to hold in memory
+	// when hashing a HTTP body. Larger bodies are written to a temporary file,
+	// which is removed when the replacement body is closed.
+	SpillThreshold int64
+
+	// TempDir is the directory to write temporary files to.
+	//
+	// If empty, the default directory for temporary files is used.
+	TempDir string
 }
 
+// DefaultMaxBytes is the default limit of bytes to read
+// when hashing a HTTP body.
+const DefaultMaxBytes = 10485760 // 10 MB
+
 // SHA256 is a digester which uses the SHA256 hashing algorithm and key,
 // with MaxBytes set to 10MB.
 var SHA256 = Digester{
 	Key:      "sha-256",
 	HashFunc: sha256.New,
-	MaxBytes: 10485760, // 10 MB
+	MaxBytes: DefaultMaxBytes,
 }
 
 // SHA384 is a digester which uses the SHA384 hashing algorithm and key,
@@ -38,7 +51,7 @@ var SHA256 = Digester{
 var SHA384 = Digester{
 	Key:      "sha-384",
 	HashFunc: sha512.New384,
-	MaxBytes: 10485760, // 10 MB
+	MaxBytes: DefaultMaxBytes,
 }
 
 // SHA512 is a digester which uses the SHA512 hashing algorithm and key,
@@ -46,7 +59,7 @@ var SHA384 = Digester{
 var SHA512 = Digester{
 	Key:      "sha-512",
 	HashFunc: sha512.New,
-	MaxBytes: 10485760, // 10 MB
+	MaxBytes: DefaultMaxBytes,
 }
 
 // HashRequest hashes a HTTP request and returns a string following the specification in
@@ -54,39 +67,83 @@ var SHA512 = Digester{
 //
 // Hashing is performed by reading the HTTP request into memory. To prevent DOS,
 // a http.MaxBytesReader is used to limit the body size that can be read.
+// If SpillThreshold is set, bodies larger than the threshold are written to a
+// temporary file instead, which is removed when r.Body is closed.
 //
 // 'w' is used to signal to the Go HTTP library that a connection should be closed
 // if the client is exceeding the maximum bytes.
 // When using this client-side, 'w' may be set to nil.
 func (d Digester) HashRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	digest, body, err := d.hashBody(w, r.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// replace the request body, as we have now read it all into memory.
+	if body != nil {
+		r.Body = body
+	}
+
+	return d.Format(digest)
+}
+
+// HashResponse hashes a HTTP response and returns a string following the specification in
+// https://www.rfc-editor.org/rfc/rfc9530.html#content-digest
+//
+// Hashing is performed by reading the HTTP response into memory. To prevent DOS,
+// a http.MaxBytesReader is used to limit the body size that can be read.
+func (d Digester) HashResponse(res *http.Response) (string, error) {
+	digest, body, err := d.hashBody(nil, res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// replace the response body, as we have now read it all into memory.
+	if body != nil {
+		res.Body = body
+	}
+
+	return d.Format(digest)
+}
+
+// hashBody reads the body into memory and hashes it.
+//
+// If the body was read, a replacement body is returned
+// which reads from the in-memory copy, or from a temporary
+// file if the body is larger than SpillThreshold.
+func (d Digester) hashBody(w http.ResponseWriter, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
 	if d.HashFunc == nil {
-		return "", errors.New("digester: getHash must be defined")
+		return nil, nil, errors.New("digester: getHash must be defined")
 	}
 	if d.Key == "" {
-		return "", errors.New("digester: key must not be empty")
+		return nil, nil, errors.New("digester: key must not be empty")
 	}
 
 	h := d.HashFunc()
 
-	var buf bytes.Buffer
+	var replacement io.ReadCloser
 
-	if r.Body != nil && r.Body != http.NoBody {
-		defer r.Body.Close()
-		maxBytesReader := http.MaxBytesReader(w, r.Body, d.MaxBytes)
+	if body != nil && body != http.NoBody {
+		defer body.Close()
+		maxBytesReader := http.MaxBytesReader(w, body, d.MaxBytes)
 
 		reader := io.TeeReader(maxBytesReader, h)
 
-		_, err := io.Copy(&buf, reader)
+		var err error
+		replacement, err = d.buffer(reader)
 		if err != nil {
-			return "", fmt.Errorf("error copying HTTP request body to hash: %w", err)
+			return nil, nil, fmt.Errorf("error copying HTTP body to hash: %w", err)
 		}
-
-		// replace the request body, as we have now read it all into memory.
-		r.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
 	}
 
-	digest := h.Sum(nil)
+	return h.Sum(nil), replacement, nil
+}
 
+// Format serializes a digest produced by HashFunc as a Content-Digest field value.
+//
+// It can be used when the digest is calculated incrementally, such as when
+// streaming a HTTP body and sending the Content-Digest field as a trailer.
+func (d Digester) Format(digest []byte) (string, error) {
 	dict := httpsfv.NewDictionary()
 	dict.Add(d.Key, httpsfv.NewItem(digest))
 
@@ -106,3 +106,29 @@ func TestDigester_HashRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestDigester_HashResponse(t *testing.T) {
+	res := http.Response{
+		Body: io.NopCloser(bytes.NewBufferString(`{"hello": "world"}`)),
+	}
+
+	got, err := SHA256.HashResponse(&res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`
+	if got != want {
+		t.Fatalf("want = %s, got = %s", want, got)
+	}
+
+	// the response body should be able to be read after hashing.
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != `{"hello": "world"}` {
+		t.Fatalf("response body was not replaced after hashing, got %s", body)
+	}
+}
@@ -0,0 +1,105 @@
+package contentdigest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrDigestRequired is returned by Middleware if a request
+// does not contain a Content-Digest header and MiddlewareOpts.Required is set.
+var ErrDigestRequired = errors.New("the request does not contain a Content-Digest header")
+
+type MiddlewareOpts struct {
+	// Required, if true, rejects requests which do not
+	// contain a Content-Digest header.
+	Required bool
+
+	// MaxBytes is the limit of bytes to read when verifying the body.
+	// Requests with larger bodies are rejected with 413 Request Entity Too Large.
+	//
+	// If zero, DefaultMaxBytes is used.
+	MaxBytes int64
+
+	// SpillThreshold and TempDir, if set, are used to write large
+	// bodies to a temporary file. See Digester.SpillThreshold.
+	SpillThreshold int64
+	TempDir        string
+
+	// OnError, if set, is called with the request context
+	// when a request is rejected.
+	OnError func(ctx context.Context, err error)
+}
+
+// Middleware is an HTTP server middleware which verifies the Content-Digest
+// header of incoming requests against the request body, independently of
+// any HTTP message signature.
+//
+// The digest is verified using the strongest supported algorithm in the header,
+// such as 'sha-256' or 'sha-512'. Requests with a mismatched or invalid digest
+// are rejected with 400 Bad Request before the handler is called.
+//
+// The body is read before calling the handler, following the same process as
+// VerifyRequest.
+func Middleware(opts MiddlewareOpts) func(next http.Handler) http.Handler {
+	d := Digester{
+		MaxBytes:       opts.MaxBytes,
+		SpillThreshold: opts.SpillThreshold,
+		TempDir:        opts.TempDir,
+	}
+	if d.MaxBytes == 0 {
+		d.MaxBytes = DefaultMaxBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			// verifying the request may replace the body with one backed by
+			// a temporary file, which is removed when the body is closed.
+			defer func() {
+				if r.Body != nil {
+					r.Body.Close()
+				}
+			}()
+
+			status, err := verifyDeclared(w, r, d, opts.Required)
+			if err != nil {
+				if opts.OnError != nil {
+					opts.OnError(r.Context(), err)
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// verifyDeclared verifies the request body against the Content-Digest header,
+// returning the HTTP status code to respond with if verification fails.
+func verifyDeclared(w http.ResponseWriter, r *http.Request, d Digester, required bool) (int, error) {
+	declared := r.Header.Values("Content-Digest")
+	if len(declared) == 0 {
+		if required {
+			return http.StatusBadRequest, ErrDigestRequired
+		}
+		return 0, nil
+	}
+
+	negotiated, err := d.Negotiate(declared)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid Content-Digest header: %w", err)
+	}
+
+	err = negotiated.VerifyRequest(w, r, declared)
+	if errors.As(err, new(*http.MaxBytesError)) {
+		return http.StatusRequestEntityTooLarge, err
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return 0, nil
+}
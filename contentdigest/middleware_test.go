@@ -0,0 +1,127 @@
+package contentdigest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	verify := Middleware(MiddlewareOpts{
+		Required: true,
+		MaxBytes: 32,
+	})
+
+	server := httptest.NewServer(verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	})))
+	defer server.Close()
+
+	type testcase struct {
+		name       string
+		transport  http.RoundTripper
+		body       string
+		header     string
+		wantStatus int
+	}
+	testcases := []testcase{
+		{
+			name:       "sha256",
+			transport:  &Transport{},
+			body:       `{"hello": "world"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sha512",
+			transport:  &Transport{Digester: SHA512},
+			body:       `{"hello": "world"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "declared_by_caller",
+			transport:  &Transport{},
+			body:       `{"hello": "world"}`,
+			header:     `sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "mismatch",
+			transport:  &Transport{},
+			body:       `{"hello": "there"}`,
+			header:     `sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported_algorithm",
+			transport:  &Transport{},
+			body:       `{"hello": "world"}`,
+			header:     `md5=:Sd/dVLAcvNLSq16eXua5uQ==:`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing",
+			transport:  http.DefaultTransport,
+			body:       `{"hello": "world"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "too_large",
+			transport:  &Transport{Digester: Digester{Key: "sha-256", HashFunc: SHA256.HashFunc, MaxBytes: 64}},
+			body:       strings.Repeat("a", 33),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &http.Client{Transport: tc.transport}
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.header != "" {
+				req.Header.Set("Content-Digest", tc.header)
+			}
+
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			got, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.StatusCode != tc.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tc.wantStatus, got)
+			}
+
+			if tc.wantStatus == http.StatusOK && string(got) != tc.body {
+				t.Fatalf("body = %s, want %s", got, tc.body)
+			}
+		})
+	}
+}
+
+func TestMiddleware_NotRequired(t *testing.T) {
+	handler := Middleware(MiddlewareOpts{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.String() != "hello" {
+		t.Fatalf("body = %s, want hello", rec.Body.String())
+	}
+}
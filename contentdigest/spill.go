@@ -0,0 +1,71 @@
+package contentdigest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// buffer reads r into memory, returning a body which reads from the copy.
+//
+// If SpillThreshold is set and r is larger than the threshold,
+// r is written to a temporary file instead.
+func (d Digester) buffer(r io.Reader) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+
+	if d.SpillThreshold <= 0 {
+		_, err := io.Copy(&buf, r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}
+
+	// read one byte past the threshold to find out
+	// whether the body needs to be written to disk.
+	n, err := io.CopyN(&buf, r, d.SpillThreshold+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n <= d.SpillThreshold {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}
+
+	f, err := os.CreateTemp(d.TempDir, "httpsig-body-*")
+	if err != nil {
+		return nil, err
+	}
+	body := &tempFileBody{file: f}
+
+	_, err = io.Copy(f, io.MultiReader(&buf, r))
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return nil, errors.Join(err, body.Close())
+	}
+
+	return body, nil
+}
+
+// tempFileBody is a HTTP body backed by a temporary
+// file, which is removed when the body is closed.
+type tempFileBody struct {
+	file *os.File
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (b *tempFileBody) Read(p []byte) (int, error) {
+	return b.file.Read(p)
+}
+
+func (b *tempFileBody) Close() error {
+	b.closeOnce.Do(func() {
+		b.closeErr = errors.Join(b.file.Close(), os.Remove(b.file.Name()))
+	})
+	return b.closeErr
+}
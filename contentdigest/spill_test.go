@@ -0,0 +1,90 @@
+package contentdigest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestDigester_SpillThreshold(t *testing.T) {
+	type testcase struct {
+		name          string
+		body          string
+		wantTempFiles int
+	}
+	testcases := []testcase{
+		{
+			name:          "below_threshold",
+			body:          `{"hello": "world"}`,
+			wantTempFiles: 0,
+		},
+		{
+			name:          "equal_to_threshold",
+			body:          `{"hello": "world!!"}`,
+			wantTempFiles: 0,
+		},
+		{
+			name:          "above_threshold",
+			body:          `{"hello": "world!!!"}`,
+			wantTempFiles: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			d := SHA256
+			d.SpillThreshold = 20
+			d.TempDir = dir
+
+			req := http.Request{
+				Body: io.NopCloser(bytes.NewBufferString(tc.body)),
+			}
+
+			_, err := d.HashRequest(nil, &req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tc.wantTempFiles {
+				t.Fatalf("want %d temporary files, got %d", tc.wantTempFiles, len(entries))
+			}
+
+			// the request body should be able to be read after hashing.
+			got, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.body {
+				t.Fatalf("request body was not replaced after hashing, got %s", got)
+			}
+
+			err = req.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// closing the body removes the temporary file.
+			entries, err = os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Fatalf("want temporary files to be removed, got %d", len(entries))
+			}
+
+			// closing the body again is a no-op.
+			err = req.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package contentdigest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Transport is a HTTP RoundTripper which adds a Content-Digest
+// header to outgoing requests, independently of any HTTP message signature.
+//
+// The request body is read into memory to calculate the digest.
+// If the request already contains a Content-Digest header, it is not modified.
+type Transport struct {
+	// Digester is used to hash the request body.
+	//
+	// If the zero value, SHA256 is used.
+	Digester Digester
+
+	// BaseTransport is the underlying HTTP transport to use
+	// for sending requests after the digest has been added.
+	//
+	// If nil, http.DefaultTransport is used.
+	BaseTransport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Content-Digest") != "" {
+		return t.base().RoundTrip(req)
+	}
+
+	d := t.Digester
+	if d.HashFunc == nil {
+		d = SHA256
+	}
+
+	// as per the http.RoundTripper contract, roundtrippers
+	// may not modify the request.
+	req2 := cloneRequest(req)
+
+	digest, err := d.HashRequest(nil, req2)
+	if err != nil {
+		// as per the http.RoundTripper contract, the request body
+		// must be closed, even on errors.
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("calculating Content-Digest: %w", err)
+	}
+
+	req2.Header.Set("Content-Digest", digest)
+
+	return t.base().RoundTrip(req2)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.BaseTransport != nil {
+		return t.BaseTransport
+	}
+	return http.DefaultTransport
+}
+
+// cloneRequest returns a clone of the provided *http.Request.
+// The clone is a shallow copy of the struct and its Header map.
+func cloneRequest(r *http.Request) *http.Request {
+	// shallow copy of the struct
+	r2 := new(http.Request)
+	*r2 = *r
+	// deep copy of the Header
+	r2.Header = make(http.Header, len(r.Header))
+	for k, s := range r.Header {
+		r2.Header[k] = append([]string(nil), s...)
+	}
+	return r2
+}
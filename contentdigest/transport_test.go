@@ -0,0 +1,45 @@
+package contentdigest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type closeTrackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeTrackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestTransport_RoundTrip_ClosesBodyOnError(t *testing.T) {
+	body := &closeTrackingBody{Reader: strings.NewReader("hello")}
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := &Transport{
+		// an empty key causes HashRequest to fail before the body is read.
+		Digester: Digester{HashFunc: SHA256.HashFunc},
+	}
+
+	_, err = tr.RoundTrip(req)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if !body.closed {
+		t.Error("expected the request body to be closed")
+	}
+
+	if req.Header.Get("Content-Digest") != "" {
+		t.Error("expected the original request to be unmodified")
+	}
+}
@@ -0,0 +1,86 @@
+package contentdigest
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrDigestMismatch is returned when the digest declared in a
+// Content-Digest field does not match the HTTP message body.
+var ErrDigestMismatch = errors.New("content digest does not match the HTTP body")
+
+// VerifyRequest hashes a HTTP request and verifies that the declared Content-Digest
+// field values contain a matching digest for the digester's algorithm.
+//
+// The request body is read into memory, following the same process as HashRequest.
+func (d Digester) VerifyRequest(w http.ResponseWriter, r *http.Request, declared []string) error {
+	want, err := d.declaredDigest(declared)
+	if err != nil {
+		return err
+	}
+
+	got, body, err := d.hashBody(w, r.Body)
+	if err != nil {
+		return err
+	}
+
+	// replace the request body, as we have now read it all into memory.
+	if body != nil {
+		r.Body = body
+	}
+
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrDigestMismatch
+	}
+
+	return nil
+}
+
+// VerifyResponse hashes a HTTP response and verifies that the declared Content-Digest
+// field values contain a matching digest for the digester's algorithm.
+//
+// The response body is read into memory, following the same process as HashResponse.
+func (d Digester) VerifyResponse(res *http.Response, declared []string) error {
+	want, err := d.declaredDigest(declared)
+	if err != nil {
+		return err
+	}
+
+	got, body, err := d.hashBody(nil, res.Body)
+	if err != nil {
+		return err
+	}
+
+	// replace the response body, as we have now read it all into memory.
+	if body != nil {
+		res.Body = body
+	}
+
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrDigestMismatch
+	}
+
+	return nil
+}
+
+// declaredDigest parses the Content-Digest field values and returns the
+// digest for the digester's algorithm.
+func (d Digester) declaredDigest(declared []string) ([]byte, error) {
+	if len(declared) == 0 {
+		return nil, errors.New("Content-Digest field was empty")
+	}
+
+	digests, err := Parse(declared)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, ok := digests[d.Key]
+	if !ok {
+		return nil, fmt.Errorf("Content-Digest field did not contain a %q digest", d.Key)
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,130 @@
+package contentdigest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDigester_VerifyRequest(t *testing.T) {
+	type testcase struct {
+		name     string
+		digester Digester
+		body     string
+		declared []string
+		wantErr  error
+	}
+	testcases := []testcase{
+		{
+			name:     "ok",
+			digester: SHA256,
+			body:     `{"hello": "world"}`,
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+		},
+		{
+			name:     "multiple_algorithms",
+			digester: SHA256,
+			body:     `{"hello": "world"}`,
+			declared: []string{`sha-512=:YQ==:, sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+		},
+		{
+			name:     "mismatch",
+			digester: SHA256,
+			body:     `{"hello": "tampered"}`,
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			wantErr:  ErrDigestMismatch,
+		},
+		{
+			name:     "missing_algorithm",
+			digester: SHA512,
+			body:     `{"hello": "world"}`,
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			wantErr:  errors.New(`Content-Digest field did not contain a "sha-512" digest`),
+		},
+		{
+			name:     "empty",
+			digester: SHA256,
+			body:     `{"hello": "world"}`,
+			wantErr:  errors.New("Content-Digest field was empty"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "https://example.com", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatalf("error constructing test HTTP request: %s", err)
+			}
+
+			err = tc.digester.VerifyRequest(nil, req, tc.declared)
+			if tc.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tc.wantErr != nil && (err == nil || err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			// the body should still be readable after verification.
+			got, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantErr == nil && string(got) != tc.body {
+				t.Fatalf("body = %q, want %q", got, tc.body)
+			}
+		})
+	}
+}
+
+func TestDigester_VerifyResponse(t *testing.T) {
+	type testcase struct {
+		name     string
+		digester Digester
+		body     string
+		declared []string
+		wantErr  error
+	}
+	testcases := []testcase{
+		{
+			name:     "ok",
+			digester: SHA256,
+			body:     `{"hello": "world"}`,
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+		},
+		{
+			name:     "mismatch",
+			digester: SHA256,
+			body:     `{"hello": "tampered"}`,
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			wantErr:  ErrDigestMismatch,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(tc.body)),
+			}
+
+			err := tc.digester.VerifyResponse(res, tc.declared)
+			if tc.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tc.wantErr != nil && (err == nil || err.Error() != tc.wantErr.Error()) {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			// the body should still be readable after verification.
+			got, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantErr == nil && string(got) != tc.body {
+				t.Fatalf("body = %q, want %q", got, tc.body)
+			}
+		})
+	}
+}
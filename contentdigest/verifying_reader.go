@@ -0,0 +1,76 @@
+package contentdigest
+
+import (
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+)
+
+// NewVerifyingReader returns a reader which hashes the body as it is read,
+// and verifies it against the declared Content-Digest field values.
+//
+// Unlike VerifyRequest, the body is not read into memory and MaxBytes is not
+// applied. Instead of io.EOF, the reader returns ErrDigestMismatch once the
+// body has been read if the digest does not match.
+//
+// Callers must read the body to EOF and check the error before trusting it.
+//
+// An error is returned if the declared field values do not
+// contain a digest for the digester's algorithm.
+func (d Digester) NewVerifyingReader(body io.ReadCloser, declared []string) (io.ReadCloser, error) {
+	if d.HashFunc == nil {
+		return nil, errors.New("digester: getHash must be defined")
+	}
+
+	want, err := d.declaredDigest(declared)
+	if err != nil {
+		return nil, err
+	}
+
+	if body == nil {
+		body = http.NoBody
+	}
+
+	return &verifyingReader{
+		body: body,
+		hash: d.HashFunc(),
+		want: want,
+	}, nil
+}
+
+type verifyingReader struct {
+	body io.ReadCloser
+	hash hash.Hash
+	want []byte
+
+	// err is the result of the digest comparison,
+	// set once the body has been read to EOF.
+	err error
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.body.Read(p)
+	r.hash.Write(p[:n])
+
+	if err == io.EOF {
+		if subtle.ConstantTimeCompare(r.hash.Sum(nil), r.want) != 1 {
+			// the final bytes are discarded, so that a mismatched
+			// body is never read to EOF successfully.
+			r.err = ErrDigestMismatch
+			return 0, r.err
+		}
+		r.err = io.EOF
+	}
+
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,92 @@
+package contentdigest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDigester_NewVerifyingReader(t *testing.T) {
+	type testcase struct {
+		name     string
+		body     io.ReadCloser
+		declared []string
+		wantErr  bool
+		want     string
+		// wantReadErr is the error returned when reading the body.
+		wantReadErr error
+	}
+	testcases := []testcase{
+		{
+			name:     "ok",
+			body:     io.NopCloser(bytes.NewBufferString(`{"hello": "world"}`)),
+			declared: []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			want:     `{"hello": "world"}`,
+		},
+		{
+			name:     "nil_body",
+			body:     nil,
+			declared: []string{`sha-256=:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=:`},
+			want:     ``,
+		},
+		{
+			name:        "mismatch",
+			body:        io.NopCloser(bytes.NewBufferString(`{"hello": "there"}`)),
+			declared:    []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			wantReadErr: ErrDigestMismatch,
+		},
+		{
+			name:        "read_error_is_propagated",
+			body:        io.NopCloser(mockReader{Err: errors.New("read error")}),
+			declared:    []string{`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			wantReadErr: errors.New("read error"),
+		},
+		{
+			name:     "missing_algorithm",
+			body:     io.NopCloser(bytes.NewBufferString(`{"hello": "world"}`)),
+			declared: []string{`sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:`},
+			wantErr:  true,
+		},
+		{
+			name:    "empty_declared",
+			body:    io.NopCloser(bytes.NewBufferString(`{"hello": "world"}`)),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := SHA256.NewVerifyingReader(tc.body, tc.declared)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("wantErr = %v, err = %s", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := io.ReadAll(body)
+			if tc.wantReadErr != nil {
+				if err == nil || err.Error() != tc.wantReadErr.Error() {
+					t.Fatalf("want read error %v, got %v", tc.wantReadErr, err)
+				}
+
+				// a mismatched body is never read to EOF successfully.
+				if errors.Is(tc.wantReadErr, ErrDigestMismatch) {
+					_, err = body.Read(make([]byte, 1))
+					if !errors.Is(err, ErrDigestMismatch) {
+						t.Fatalf("want read error %v after EOF, got %v", ErrDigestMismatch, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tc.want {
+				t.Fatalf("want = %s, got = %s", tc.want, got)
+			}
+		})
+	}
+}
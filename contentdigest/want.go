@@ -0,0 +1,172 @@
+package contentdigest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/dunglas/httpsfv"
+)
+
+// Preference is a digest algorithm preference from a
+// Want-Content-Digest or Want-Repr-Digest field.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9530.html#section-4
+type Preference struct {
+	// Algorithm is the algorithm key, such as 'sha-256'.
+	Algorithm string
+
+	// Weight is the preference for the algorithm, from 0 to 10.
+	// A weight of 0 means that the algorithm is not acceptable.
+	Weight int64
+}
+
+// ParsePreferences parses the values of a Want-Content-Digest
+// or Want-Repr-Digest field.
+func ParsePreferences(values []string) ([]Preference, error) {
+	dict, err := httpsfv.UnmarshalDictionary(values)
+	if err != nil {
+		return nil, fmt.Errorf("parsing digest preferences: %w", err)
+	}
+
+	var prefs []Preference
+
+	for _, key := range dict.Names() {
+		member, _ := dict.Get(key)
+
+		item, ok := member.(httpsfv.Item)
+		if !ok {
+			return nil, fmt.Errorf("could not cast %q preference to a httpsfv.Item", key)
+		}
+
+		weight, ok := item.Value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("%q preference was not an integer", key)
+		}
+		if weight < 0 || weight > 10 {
+			return nil, fmt.Errorf("%q preference must be between 0 and 10, got %d", key, weight)
+		}
+
+		prefs = append(prefs, Preference{Algorithm: key, Weight: weight})
+	}
+
+	return prefs, nil
+}
+
+// FormatPreferences serializes digest algorithm preferences as a
+// Want-Content-Digest or Want-Repr-Digest field value.
+func FormatPreferences(prefs []Preference) (string, error) {
+	dict := httpsfv.NewDictionary()
+
+	for _, p := range prefs {
+		if p.Weight < 0 || p.Weight > 10 {
+			return "", fmt.Errorf("%q preference must be between 0 and 10, got %d", p.Algorithm, p.Weight)
+		}
+		dict.Add(p.Algorithm, httpsfv.NewItem(p.Weight))
+	}
+
+	return httpsfv.Marshal(dict)
+}
+
+// Select returns the strongest supported algorithm which is acceptable
+// to the recipient, ignoring algorithms with a weight of 0.
+//
+// The returned bool is false if none of the preferred algorithms are supported.
+func Select(prefs []Preference) (Digester, bool) {
+	return selectAtLeast(prefs, len(Algorithms)-1)
+}
+
+// selectAtLeast returns the strongest supported algorithm which is acceptable
+// to the recipient and has a strength of at most minimum, as returned by strength.
+func selectAtLeast(prefs []Preference, minimum int) (Digester, bool) {
+	best := ""
+	for _, p := range prefs {
+		s := strength(p.Algorithm)
+		if p.Weight == 0 || s == -1 || s > minimum {
+			continue
+		}
+		if best == "" || s < strength(best) {
+			best = p.Algorithm
+		}
+	}
+
+	if best == "" {
+		return Digester{}, false
+	}
+
+	return Lookup(best)
+}
+
+// Negotiator tracks the digest algorithm preferred by a server,
+// based on the Want-Content-Digest or Want-Repr-Digest fields in its responses.
+//
+// It is safe for concurrent use.
+type Negotiator struct {
+	mu sync.Mutex
+	// prefs are the preferences from the most recent response,
+	// or nil if an algorithm has not been negotiated.
+	prefs []Preference
+}
+
+// Update selects the strongest mutually supported algorithm from the
+// Want-Content-Digest field in a response, falling back to the
+// Want-Repr-Digest field.
+//
+// If the response does not contain either field, the negotiated algorithm is unchanged.
+// If none of the preferred algorithms are supported, the negotiated algorithm is reset.
+func (n *Negotiator) Update(h http.Header) error {
+	values := h.Values("Want-Content-Digest")
+	if len(values) == 0 {
+		values = h.Values("Want-Repr-Digest")
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	prefs, err := ParsePreferences(values)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.prefs = nil
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := Select(prefs); !ok {
+		return errors.New("none of the preferred digest algorithms are supported")
+	}
+
+	n.prefs = prefs
+	return nil
+}
+
+// Digester returns a copy of d using the strongest negotiated algorithm which
+// is at least as strong as the algorithm of d, keeping the other settings of d.
+//
+// Weaker algorithms are not used, as the recipient may reject digests which are
+// weaker than the algorithm of the signing key. If an algorithm has not been
+// negotiated, or none of the preferred algorithms are strong enough, d is returned.
+// If d uses an algorithm which is not in Algorithms, d is returned.
+func (n *Negotiator) Digester(d Digester) Digester {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	minimum := strength(d.Key)
+	switch {
+	case d.Key == "":
+		minimum = len(Algorithms) - 1
+	case minimum == -1:
+		return d
+	}
+
+	selected, ok := selectAtLeast(n.prefs, minimum)
+	if !ok {
+		return d
+	}
+
+	negotiated, _ := d.WithAlgorithm(selected.Key)
+	return negotiated
+}
@@ -0,0 +1,179 @@
+package contentdigest
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var cmpSortStrings = cmp.Transformer("sort", func(in []string) []string {
+	out := append([]string(nil), in...)
+	sort.Strings(out)
+	return out
+})
+
+func TestParsePreferences(t *testing.T) {
+	type testcase struct {
+		name    string
+		values  []string
+		want    []Preference
+		wantErr bool
+	}
+	testcases := []testcase{
+		{
+			name:   "ok",
+			values: []string{`sha-256=1, sha-512=3`},
+			want: []Preference{
+				{Algorithm: "sha-256", Weight: 1},
+				{Algorithm: "sha-512", Weight: 3},
+			},
+		},
+		{
+			name:    "weight_too_large",
+			values:  []string{`sha-256=11`},
+			wantErr: true,
+		},
+		{
+			name:    "weight_not_integer",
+			values:  []string{`sha-256=:AA==:`},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePreferences(tc.values)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("wantErr = %v, err = %v", tc.wantErr, err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("ParsePreferences() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatPreferences(t *testing.T) {
+	got, err := FormatPreferences([]Preference{
+		{Algorithm: "sha-512", Weight: 10},
+		{Algorithm: "sha-256", Weight: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `sha-512=10, sha-256=1`
+	if got != want {
+		t.Fatalf("want = %s, got = %s", want, got)
+	}
+
+	_, err = FormatPreferences([]Preference{{Algorithm: "sha-256", Weight: -1}})
+	if err == nil {
+		t.Fatal("expected an error formatting an invalid weight")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	type testcase struct {
+		name   string
+		prefs  []Preference
+		want   string
+		wantOK bool
+	}
+	testcases := []testcase{
+		{
+			name: "strongest_mutually_supported",
+			prefs: []Preference{
+				{Algorithm: "sha-256", Weight: 5},
+				{Algorithm: "sha-384", Weight: 1},
+				{Algorithm: "unixsum", Weight: 10},
+			},
+			want:   "sha-384",
+			wantOK: true,
+		},
+		{
+			name: "zero_weight_is_not_acceptable",
+			prefs: []Preference{
+				{Algorithm: "sha-256", Weight: 1},
+				{Algorithm: "sha-512", Weight: 0},
+			},
+			want:   "sha-256",
+			wantOK: true,
+		},
+		{
+			name: "none_supported",
+			prefs: []Preference{
+				{Algorithm: "md5", Weight: 1},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := Select(tc.prefs)
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if got.Key != tc.want {
+				t.Fatalf("Key = %s, want %s", got.Key, tc.want)
+			}
+		})
+	}
+}
+
+func TestNegotiator(t *testing.T) {
+	var n Negotiator
+
+	d := SHA256
+	d.MaxBytes = 10
+
+	// an algorithm has not been negotiated.
+	if got := n.Digester(d); got.Key != "sha-256" {
+		t.Fatalf("Key = %s, want sha-256", got.Key)
+	}
+
+	err := n.Update(http.Header{"Want-Content-Digest": {"sha-256=1, sha-512=1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := n.Digester(d)
+	if got.Key != "sha-512" || got.MaxBytes != 10 {
+		t.Fatalf("Digester() = %s with MaxBytes %d, want sha-512 with MaxBytes 10", got.Key, got.MaxBytes)
+	}
+
+	// a response without preferences does not change the algorithm.
+	err = n.Update(http.Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Digester(d); got.Key != "sha-512" {
+		t.Fatalf("Key = %s, want sha-512", got.Key)
+	}
+
+	// Want-Repr-Digest is used if Want-Content-Digest is not present.
+	err = n.Update(http.Header{"Want-Repr-Digest": {"sha-384=1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Digester(d); got.Key != "sha-384" {
+		t.Fatalf("Key = %s, want sha-384", got.Key)
+	}
+
+	// algorithms weaker than the algorithm of the digester are not used.
+	if got := n.Digester(SHA512); got.Key != "sha-512" {
+		t.Fatalf("Key = %s, want sha-512", got.Key)
+	}
+
+	// unsupported preferences reset the negotiated algorithm.
+	err = n.Update(http.Header{"Want-Content-Digest": {"md5=1"}})
+	if err == nil {
+		t.Fatal("expected an error for unsupported preferences")
+	}
+	if got := n.Digester(d); got.Key != "sha-256" {
+		t.Fatalf("Key = %s, want sha-256", got.Key)
+	}
+}
@@ -0,0 +1,116 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/sigparams"
+)
+
+func TestE2E_AcceptSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:       "foo",
+		Scheme:    "http",
+		Authority: strings.TrimPrefix(server.URL, "http://"),
+		Validation: &sigparams.ValidateOpts{
+			BeforeDuration: time.Minute,
+			RequiredCoveredComponents: map[string]bool{
+				"@method":        true,
+				"@target-uri":    true,
+				"content-length": true,
+				"content-digest": true,
+				"x-request-id":   true,
+			},
+			RequireNonce: true,
+		},
+		SendAcceptSignature: true,
+		AcceptSignatureAlg:  "ecdsa-p256-sha256",
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	})))
+
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString("hello, world!"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Request-Id", "123")
+		return req
+	}
+
+	// the client does not cover x-request-id, so the request is rejected.
+	client := httpsig.NewClient(httpsig.ClientOpts{
+		Tag:               "foo",
+		Alg:               alg_ecdsa.NewP256Signer(key),
+		CoveredComponents: []string{"@method", "@target-uri"},
+	})
+
+	res, err := client.Do(newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 but got %d", res.StatusCode)
+	}
+
+	wantAccept := `sig1=("@method" "@target-uri" "content-digest" "content-length" "x-request-id");alg="ecdsa-p256-sha256";tag="foo";nonce;created`
+	if got := res.Header.Get("Accept-Signature"); got != wantAccept {
+		t.Fatalf("Accept-Signature = %s, want %s", got, wantAccept)
+	}
+
+	// with retries enabled, the client re-signs the request
+	// to cover the components requested by the server.
+	client = httpsig.NewClient(httpsig.ClientOpts{
+		Tag:                    "foo",
+		Alg:                    alg_ecdsa.NewP256Signer(key),
+		CoveredComponents:      []string{"@method", "@target-uri"},
+		RetryOnAcceptSignature: true,
+	})
+
+	res, err = client.Do(newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d: %s", res.StatusCode, got)
+	}
+
+	if string(got) != "hello, world!" {
+		t.Fatalf("response not as expected: got %s", got)
+	}
+}
@@ -0,0 +1,279 @@
+package e2e
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/alg_hmac"
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// recordingTransport records the headers of the requests it sends.
+type recordingTransport struct {
+	headers []http.Header
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.headers = append(t.headers, req.Header.Clone())
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestE2E_DigestNegotiation(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:       "foo",
+		Scheme:    "http",
+		Authority: strings.TrimPrefix(server.URL, "http://"),
+		WantContentDigest: []contentdigest.Preference{
+			{Algorithm: "sha-256", Weight: 5},
+			{Algorithm: "sha-384", Weight: 1},
+			{Algorithm: "sha-512", Weight: 0},
+		},
+		WantReprDigest: []contentdigest.Preference{
+			{Algorithm: "sha-256", Weight: 1},
+		},
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	})))
+
+	recorder := &recordingTransport{}
+
+	client := &http.Client{
+		Transport: &signer.Transport{
+			Tag:                     "foo",
+			Alg:                     alg_ecdsa.NewP256Signer(key),
+			CoveredComponents:       append(httpsig.DefaultCoveredComponents(), "repr-digest"),
+			BaseTransport:           recorder,
+			ContentDigestNegotiator: &contentdigest.Negotiator{},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Post(server.URL, "text/plain", strings.NewReader("hello, world!"))
+		if err != nil {
+			t.Fatalf("client post error: %v", err)
+		}
+
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200 but got %d: %s", res.StatusCode, got)
+		}
+		if string(got) != "hello, world!" {
+			t.Fatalf("response not as expected: got %s", got)
+		}
+
+		if got := res.Header.Get("Want-Content-Digest"); got != "sha-256=5, sha-384=1, sha-512=0" {
+			t.Fatalf("Want-Content-Digest = %s", got)
+		}
+		if got := res.Header.Get("Want-Repr-Digest"); got != "sha-256=1" {
+			t.Fatalf("Want-Repr-Digest = %s", got)
+		}
+	}
+
+	// the first request uses the algorithm's default digest, and the second
+	// request uses the strongest algorithm which is acceptable to the server.
+	wantPrefixes := []string{"sha-256=", "sha-384="}
+
+	for i, want := range wantPrefixes {
+		h := recorder.headers[i]
+		for _, field := range []string{"Content-Digest", "Repr-Digest"} {
+			if got := h.Get(field); !strings.HasPrefix(got, want) {
+				t.Fatalf("request %d: %s = %s, want prefix %s", i, field, got, want)
+			}
+		}
+	}
+}
+
+func TestE2E_DigestNegotiation_WeakerAlgorithm(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.P384StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:       "foo",
+		Scheme:    "http",
+		Authority: strings.TrimPrefix(server.URL, "http://"),
+		WantContentDigest: []contentdigest.Preference{
+			{Algorithm: "sha-256", Weight: 10},
+		},
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	})))
+
+	recorder := &recordingTransport{}
+
+	client := &http.Client{
+		Transport: &signer.Transport{
+			Tag:                     "foo",
+			Alg:                     alg_ecdsa.NewP384Signer(key),
+			CoveredComponents:       httpsig.DefaultCoveredComponents(),
+			BaseTransport:           recorder,
+			ContentDigestNegotiator: &contentdigest.Negotiator{},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Post(server.URL, "text/plain", strings.NewReader("hello, world!"))
+		if err != nil {
+			t.Fatalf("client post error: %v", err)
+		}
+
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("error reading response body: %v", err)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: expected status 200 but got %d: %s", i, res.StatusCode, got)
+		}
+	}
+
+	// the server's preferred algorithm is weaker than the key's
+	// digest algorithm, so the key's digest algorithm is kept.
+	for i, h := range recorder.headers {
+		if got := h.Get("Content-Digest"); !strings.HasPrefix(got, "sha-384=") {
+			t.Fatalf("request %d: Content-Digest = %s, want prefix sha-384=", i, got)
+		}
+	}
+}
+
+func TestE2E_ReprDigestMismatch(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:       "foo",
+		Scheme:    "http",
+		Authority: strings.TrimPrefix(server.URL, "http://"),
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	})))
+
+	client := &http.Client{
+		Transport: &signer.Transport{
+			Tag:               "foo",
+			Alg:               alg_ecdsa.NewP256Signer(key),
+			CoveredComponents: append(httpsig.DefaultCoveredComponents(), "repr-digest"),
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("hello, world!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	// the declared representation digest does not match the body.
+	req.Header.Set("Repr-Digest", "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:")
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("client post error: %v", err)
+	}
+	defer res.Body.Close()
+
+	var problem httpsig.Problem
+	err = json.NewDecoder(res.Body).Decode(&problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 but got %d", res.StatusCode)
+	}
+	if problem.Reason != verifier.ReasonContentDigestMismatch {
+		t.Fatalf("reason = %s, want %s", problem.Reason, verifier.ReasonContentDigestMismatch)
+	}
+}
+
+func TestE2E_DigestNegotiation_InvalidPreferences(t *testing.T) {
+	var validationErrs []error
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_hmac.NewSingleHMACKeyDirectory(alg_hmac.NewHMAC([]byte("secret"))),
+		Tag:          "foo",
+		Scheme:       "http",
+		Authority:    "example.com",
+		WantContentDigest: []contentdigest.Preference{
+			{Algorithm: "sha-256", Weight: 11},
+		},
+		OnValidationError: func(ctx context.Context, err error) {
+			validationErrs = append(validationErrs, err)
+		},
+	})
+
+	handler := verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("request %d: status = %d, want %d", i, rec.Code, http.StatusInternalServerError)
+		}
+	}
+
+	// the configuration error is only reported once.
+	if len(validationErrs) != 1 {
+		t.Fatalf("expected the invalid preferences to be reported once, got %d errors", len(validationErrs))
+	}
+}
@@ -0,0 +1,110 @@
+package e2e
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_hmac"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// TestE2E_Policies tests that an endpoint can accept signatures
+// with different tags, each verified with its own keys.
+func TestE2E_Policies(t *testing.T) {
+	partnerKey := alg_hmac.NewHMACWithAttributes([]byte("partner-secret"), "partner")
+	internalKey := alg_hmac.NewHMACWithAttributes([]byte("internal-secret"), "internal")
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		Policies: []verifier.Policy{
+			{Tag: "partner-webhook", KeyDirectory: alg_hmac.NewSingleHMACKeyDirectory(partnerKey)},
+			{Tag: "internal", KeyDirectory: alg_hmac.NewSingleHMACKeyDirectory(internalKey)},
+		},
+		PolicyMatch: verifier.MatchAny,
+		Scheme:      "http",
+		Authority:   strings.TrimPrefix(server.URL, "http://"),
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "hello, %s!", httpsig.AttributesFromContext(r.Context()))
+	})))
+
+	tests := []struct {
+		name       string
+		tag        string
+		alg        signer.Algorithm
+		wantStatus int
+		want       string
+	}{
+		{
+			name:       "partner",
+			tag:        "partner-webhook",
+			alg:        partnerKey,
+			wantStatus: http.StatusOK,
+			want:       "hello, partner!",
+		},
+		{
+			name:       "internal",
+			tag:        "internal",
+			alg:        internalKey,
+			wantStatus: http.StatusOK,
+			want:       "hello, internal!",
+		},
+		{
+			name:       "partner_key_with_internal_tag",
+			tag:        "internal",
+			alg:        partnerKey,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown_tag",
+			tag:        "other",
+			alg:        partnerKey,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := httpsig.NewClient(httpsig.ClientOpts{
+				Tag: tt.tag,
+				Alg: tt.alg,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tt.wantStatus, body)
+			}
+
+			if tt.want != "" && string(body) != tt.want {
+				t.Errorf("body = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package e2e
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+func TestE2E_SignedResponse(t *testing.T) {
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	signResponses := httpsig.ResponseSigningMiddleware(httpsig.ResponseSigningOpts{
+		Tag:               "server",
+		Alg:               alg_ecdsa.NewP256Signer(serverKey),
+		CoveredComponents: []string{"@status", "content-length", "content-digest", "@method;req", "@target-uri;req"},
+	})
+
+	server := httptest.NewServer(signResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Unsigned", "foo")
+		_, _ = w.Write([]byte("hello, world!"))
+	})))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &signer.Transport{
+			Tag:               "client",
+			Alg:               alg_ecdsa.NewP256Signer(clientKey),
+			CoveredComponents: []string{"@method", "@target-uri"},
+			ResponseVerifier: &verifier.Verifier{
+				NonceStorage: inmemory.NewNonceStorage(),
+				KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+					Key: &serverKey.PublicKey,
+				},
+				Tag: "server",
+				Validation: sigparams.ValidateOpts{
+					BeforeDuration: time.Minute,
+					RequiredCoveredComponents: map[string]bool{
+						"@status":         true,
+						"@method;req":     true,
+						"@target-uri;req": true,
+					},
+					RequireNonce: true,
+				},
+			},
+		},
+	}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("client get error: %v", err)
+	}
+	defer res.Body.Close()
+
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	if string(got) != "hello, world!" {
+		t.Fatalf("response not as expected: got %s", got)
+	}
+
+	// the covered Content-Digest field must be present in the response
+	// sent by the server.
+	raw, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	defer raw.Body.Close()
+
+	rawBody, err := io.ReadAll(raw.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	sum := sha256.Sum256(rawBody)
+	wantDigest, err := contentdigest.SHA256.Format(sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotDigest := raw.Header.Get("Content-Digest"); gotDigest != wantDigest {
+		t.Fatalf("Content-Digest = %q, want %q", gotDigest, wantDigest)
+	}
+
+	if res.Header.Get("X-Unsigned") != "" {
+		t.Fatalf("expected uncovered response header to be removed")
+	}
+
+	// a response signed with a different key must be rejected.
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	client.Transport.(*signer.Transport).ResponseVerifier.KeyDirectory = alg_ecdsa.StaticKeyDirectory{
+		Key: &otherKey.PublicKey,
+	}
+
+	_, err = client.Get(server.URL)
+	if err == nil {
+		t.Fatal("expected an error verifying a response signed with a different key")
+	}
+}
+
+// sha512Signer signs response bodies using a SHA-512 digest,
+// rather than the SHA-256 digest used by P-256 keys.
+type sha512Signer struct {
+	*alg_ecdsa.P256
+}
+
+func (s sha512Signer) ContentDigest() contentdigest.Digester {
+	return contentdigest.SHA512
+}
+
+func TestE2E_SignedResponse_DeclaredDigest(t *testing.T) {
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	signResponses := httpsig.ResponseSigningMiddleware(httpsig.ResponseSigningOpts{
+		Tag: "server",
+		Alg: sha512Signer{alg_ecdsa.NewP256Signer(serverKey)},
+	})
+
+	server := httptest.NewServer(signResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello, world!"))
+	})))
+	defer server.Close()
+
+	v := &verifier.Verifier{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &serverKey.PublicKey,
+		},
+		Tag: "server",
+		Validation: sigparams.ValidateOpts{
+			BeforeDuration: time.Minute,
+			RequireNonce:   true,
+		},
+	}
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if !strings.HasPrefix(res.Header.Get("Content-Digest"), "sha-512=") {
+		t.Fatalf("expected a sha-512 Content-Digest, got %q", res.Header.Get("Content-Digest"))
+	}
+
+	// the verifier's key uses SHA-256, but the signature
+	// covers the SHA-512 digest sent in the response.
+	verified, _, err := v.ParseResponse(res, time.Now())
+	if err != nil {
+		t.Fatalf("verifying response: %v", err)
+	}
+
+	got, err := io.ReadAll(verified.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	if string(got) != "hello, world!" {
+		t.Fatalf("response not as expected: got %s", got)
+	}
+}
@@ -0,0 +1,237 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_hmac"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+func TestE2E_RoutePolicies(t *testing.T) {
+	key := alg_hmac.NewHMAC([]byte("secret"))
+
+	// the middleware is used as the server's handler directly, as a
+	// http.ServeMux would redirect requests with unclean paths.
+	server := httptest.NewUnstartedServer(nil)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_hmac.NewSingleHMACKeyDirectory(key),
+		Tag:          "foo",
+		Scheme:       "http",
+		Authority:    server.Listener.Addr().String(),
+		Routes: []httpsig.RoutePolicy{
+			{
+				Pattern:  "GET /health",
+				Unsigned: true,
+			},
+			{
+				Pattern:                   "POST /payments",
+				RequiredCoveredComponents: []string{"@method", "@target-uri", "content-digest", "idempotency-key"},
+			},
+			{
+				Pattern:           "POST /admin/",
+				AllowedAlgorithms: []string{"ecdsa-p256-sha256"},
+			},
+			{
+				Pattern:      "POST /uploads",
+				MaxBodyBytes: 16,
+			},
+		},
+	})
+
+	server.Config.Handler = verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.Start()
+
+	defaultComponents := []string{"@method", "@target-uri", "content-type", "content-length", "content-digest"}
+
+	tests := []struct {
+		name string
+		// components is nil if the request is not signed.
+		components []string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantReason verifier.Reason
+	}{
+		{
+			name:       "unsigned_health_check",
+			method:     http.MethodGet,
+			path:       "/health",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unsigned_request_to_other_route",
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonSignatureNotFound,
+		},
+		{
+			name:       "unsigned_health_check_with_dot_segments",
+			method:     http.MethodGet,
+			path:       "/payments/../health",
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonSignatureNotFound,
+		},
+		{
+			name:       "unsigned_health_check_with_double_slash",
+			method:     http.MethodGet,
+			path:       "//health",
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonSignatureNotFound,
+		},
+		{
+			name:       "health_check_requires_signature_for_other_methods",
+			method:     http.MethodPost,
+			path:       "/health",
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonSignatureNotFound,
+		},
+		{
+			name:       "payment_with_idempotency_key",
+			components: append(defaultComponents, "idempotency-key"),
+			method:     http.MethodPost,
+			path:       "/payments",
+			body:       `{"amount":100}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "payment_without_idempotency_key",
+			components: defaultComponents,
+			method:     http.MethodPost,
+			path:       "/payments",
+			body:       `{"amount":100}`,
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonMissingComponent,
+		},
+		{
+			name:       "algorithm_not_allowed",
+			components: defaultComponents,
+			method:     http.MethodPost,
+			path:       "/admin/users",
+			body:       "{}",
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonAlgorithmNotAllowed,
+		},
+		{
+			name:       "missing_trailing_slash_uses_default_options",
+			components: defaultComponents,
+			method:     http.MethodPost,
+			path:       "/admin",
+			body:       "{}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "upload_within_limit",
+			components: defaultComponents,
+			method:     http.MethodPost,
+			path:       "/uploads",
+			body:       "small",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "upload_too_large",
+			components: defaultComponents,
+			method:     http.MethodPost,
+			path:       "/uploads",
+			body:       strings.Repeat("a", 17),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantReason: verifier.ReasonBodyTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := http.DefaultClient
+			if tt.components != nil {
+				client = &http.Client{
+					Transport: &signer.Transport{
+						KeyID:             "key",
+						Tag:               "foo",
+						Alg:               key,
+						CoveredComponents: tt.components,
+					},
+				}
+			}
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", "8e03978e-40d5-43e8-bc93-6894a57f9324")
+
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				body, _ := io.ReadAll(res.Body)
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tt.wantStatus, body)
+			}
+
+			if tt.wantReason == "" {
+				return
+			}
+
+			var problem httpsig.Problem
+			err = json.NewDecoder(res.Body).Decode(&problem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if problem.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q: %s", problem.Reason, tt.wantReason, problem.Detail)
+			}
+		})
+	}
+}
+
+func TestE2E_RoutePolicies_InvalidPattern(t *testing.T) {
+	var validationErr error
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_hmac.NewSingleHMACKeyDirectory(alg_hmac.NewHMAC([]byte("secret"))),
+		Tag:          "foo",
+		Scheme:       "http",
+		Authority:    "example.com",
+		Routes: []httpsig.RoutePolicy{
+			{Pattern: "GET /health", Unsigned: true},
+			{Pattern: "GET /health", Unsigned: true},
+		},
+		OnValidationError: func(ctx context.Context, err error) {
+			validationErr = err
+		},
+	})
+
+	handler := verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/health", nil))
+
+	if rec.Code == http.StatusOK {
+		t.Fatalf("expected request to be rejected when the routes are invalid")
+	}
+
+	if validationErr == nil {
+		t.Fatalf("expected the invalid routes to be reported to OnValidationError")
+	}
+}
@@ -0,0 +1,130 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_hmac"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// TestE2E_SignatureChain tests that a server can verify a client
+// signature which has been countersigned by a proxy.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-4.3
+func TestE2E_SignatureChain(t *testing.T) {
+	clientKey := alg_hmac.NewHMAC([]byte("client-secret"))
+	proxyKey := alg_hmac.NewHMAC([]byte("proxy-secret"))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_hmac.NewMultiHMACKeyDirectory(map[string]alg_hmac.HMAC{
+			"client": *clientKey,
+			"proxy":  *proxyKey,
+		}),
+		Tag:       "proxy",
+		Chain:     []string{"client"},
+		Scheme:    "http",
+		Authority: strings.TrimPrefix(server.URL, "http://"),
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})))
+
+	covered := []string{"@method", "@target-uri", "content-type", "content-length", "content-digest"}
+
+	tests := []struct {
+		name string
+		// clientAlg is nil if the client does not sign the request.
+		clientAlg            signer.Algorithm
+		coveredSignatureTags []string
+		wantStatus           int
+		wantReason           verifier.Reason
+	}{
+		{
+			name:                 "ok",
+			clientAlg:            clientKey,
+			coveredSignatureTags: []string{"client"},
+			wantStatus:           http.StatusOK,
+		},
+		{
+			name:       "proxy_does_not_cover_client_signature",
+			clientAlg:  clientKey,
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonSignatureNotCovered,
+		},
+		{
+			name:                 "invalid_client_signature",
+			clientAlg:            alg_hmac.NewHMAC([]byte("wrong-secret")),
+			coveredSignatureTags: []string{"client"},
+			wantStatus:           http.StatusUnauthorized,
+			wantReason:           verifier.ReasonInvalidSignature,
+		},
+		{
+			name:       "missing_client_signature",
+			wantStatus: http.StatusUnauthorized,
+			wantReason: verifier.ReasonSignatureNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the proxy countersigns the request after the client has signed it.
+			var transport http.RoundTripper = &signer.Transport{
+				KeyID:                "proxy",
+				Tag:                  "proxy",
+				Alg:                  proxyKey,
+				CoveredComponents:    covered,
+				CoveredSignatureTags: tt.coveredSignatureTags,
+			}
+
+			if tt.clientAlg != nil {
+				transport = &signer.Transport{
+					KeyID:             "client",
+					Tag:               "client",
+					Alg:               tt.clientAlg,
+					CoveredComponents: covered,
+					BaseTransport:     transport,
+				}
+			}
+
+			client := &http.Client{Transport: transport}
+
+			res, err := client.Post(server.URL+"/orders", "application/json", strings.NewReader(`{"item":"book"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				body, _ := io.ReadAll(res.Body)
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tt.wantStatus, body)
+			}
+
+			if tt.wantReason == "" {
+				return
+			}
+
+			var problem httpsig.Problem
+			err = json.NewDecoder(res.Body).Decode(&problem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if problem.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q: %s", problem.Reason, tt.wantReason, problem.Detail)
+			}
+		})
+	}
+}
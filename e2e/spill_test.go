@@ -0,0 +1,199 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+)
+
+func TestE2E_SpillThreshold(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	dir := t.TempDir()
+
+	countTempFiles := func() int {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:            "foo",
+		Scheme:         "http",
+		Authority:      strings.TrimPrefix(server.URL, "http://"),
+		SpillThreshold: 16,
+		TempDir:        dir,
+	})
+
+	var tempFilesInHandler int
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tempFilesInHandler = countTempFiles()
+		_, _ = io.Copy(w, r.Body)
+	})))
+
+	body := "a body which is larger than the threshold"
+
+	tests := []struct {
+		name                   string
+		base                   http.RoundTripper
+		wantStatus             int
+		wantTempFilesInHandler int
+	}{
+		{
+			name:                   "ok",
+			wantStatus:             http.StatusOK,
+			wantTempFilesInHandler: 1,
+		},
+		{
+			name:       "tampered_body",
+			base:       tamperTransport{body: bytes.Repeat([]byte("b"), len(body))},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFilesInHandler = 0
+
+			client := &http.Client{
+				Transport: &signer.Transport{
+					Tag:               "foo",
+					Alg:               alg_ecdsa.NewP256Signer(key),
+					CoveredComponents: httpsig.DefaultCoveredComponents(),
+					BaseTransport:     tt.base,
+				},
+			}
+
+			res, err := client.Post(server.URL, "text/plain", strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("client post error: %v", err)
+			}
+			defer res.Body.Close()
+
+			got, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tt.wantStatus, got)
+			}
+			if tt.wantStatus == http.StatusOK && string(got) != body {
+				t.Fatalf("body = %q, want %q", got, body)
+			}
+
+			if tempFilesInHandler != tt.wantTempFilesInHandler {
+				t.Fatalf("temporary files during handler = %d, want %d", tempFilesInHandler, tt.wantTempFilesInHandler)
+			}
+
+			// the temporary file is removed once the request has been handled.
+			if n := countTempFiles(); n != 0 {
+				t.Fatalf("temporary files after request = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestE2E_SpillThreshold_MaxBodyBytes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:            "foo",
+		Scheme:         "http",
+		Authority:      strings.TrimPrefix(server.URL, "http://"),
+		SpillThreshold: 1024,
+		TempDir:        t.TempDir(),
+		MaxBodyBytes:   contentdigest.DefaultMaxBytes + 1024,
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		_, _ = fmt.Fprint(w, n)
+	})))
+
+	client := &http.Client{
+		Transport: &signer.Transport{
+			Tag:               "foo",
+			Alg:               largeBodySigner{alg_ecdsa.NewP256Signer(key)},
+			CoveredComponents: httpsig.DefaultCoveredComponents(),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int
+	}{
+		{
+			name:       "larger_than_default_limit",
+			size:       contentdigest.DefaultMaxBytes + 1,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "larger_than_max_body_bytes",
+			size:       contentdigest.DefaultMaxBytes + 1025,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Post(server.URL, "application/octet-stream", bytes.NewReader(make([]byte, tt.size)))
+			if err != nil {
+				t.Fatalf("client post error: %v", err)
+			}
+			defer res.Body.Close()
+
+			got, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tt.wantStatus, got)
+			}
+			if tt.wantStatus == http.StatusOK && string(got) != strconv.Itoa(tt.size) {
+				t.Fatalf("body length = %s, want %d", got, tt.size)
+			}
+		})
+	}
+}
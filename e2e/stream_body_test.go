@@ -0,0 +1,167 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+)
+
+// tamperTransport replaces the request body after it has been signed.
+type tamperTransport struct {
+	body []byte
+}
+
+func (t tamperTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Body = io.NopCloser(bytes.NewReader(t.body))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// largeBodySigner allows the client to sign bodies larger than
+// contentdigest.DefaultMaxBytes.
+type largeBodySigner struct {
+	*alg_ecdsa.P256
+}
+
+func (s largeBodySigner) ContentDigest() contentdigest.Digester {
+	d := contentdigest.SHA256
+	d.MaxBytes = 4 * contentdigest.DefaultMaxBytes
+	return d
+}
+
+func TestE2E_StreamBody(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:        "foo",
+		Scheme:     "http",
+		Authority:  strings.TrimPrefix(server.URL, "http://"),
+		StreamBody: true,
+	})
+
+	var gotTransferEncoding []string
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTransferEncoding = r.TransferEncoding
+		n, err := io.Copy(io.Discard, r.Body)
+		if errors.Is(err, contentdigest.ErrDigestMismatch) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "read %d bytes", n)
+	})))
+
+	// the body is larger than contentdigest.DefaultMaxBytes, which
+	// would be rejected if the body was read into memory.
+	body := bytes.Repeat([]byte("a"), contentdigest.DefaultMaxBytes+1)
+
+	tests := []struct {
+		name string
+		body []byte
+		// unknownLength streams the body from a pipe, so that
+		// its length is unknown when the request is signed.
+		unknownLength bool
+		base          http.RoundTripper
+		wantStatus    int
+		wantBody      string
+	}{
+		{
+			name:       "ok",
+			body:       body,
+			wantStatus: http.StatusOK,
+			wantBody:   fmt.Sprintf("read %d bytes", len(body)),
+		},
+		{
+			name:       "tampered_body",
+			body:       body,
+			base:       tamperTransport{body: bytes.Repeat([]byte("b"), len(body))},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   contentdigest.ErrDigestMismatch.Error() + "\n",
+		},
+		{
+			name:          "unknown_length",
+			body:          body,
+			unknownLength: true,
+			wantStatus:    http.StatusOK,
+			wantBody:      fmt.Sprintf("read %d bytes", len(body)),
+		},
+		{
+			name:          "unknown_length_empty",
+			body:          []byte{},
+			unknownLength: true,
+			wantStatus:    http.StatusOK,
+			wantBody:      "read 0 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{
+				Transport: &signer.Transport{
+					Tag:               "foo",
+					Alg:               largeBodySigner{alg_ecdsa.NewP256Signer(key)},
+					CoveredComponents: httpsig.DefaultCoveredComponents(),
+					BaseTransport:     tt.base,
+				},
+			}
+
+			var reqBody io.Reader = bytes.NewReader(tt.body)
+			if tt.unknownLength {
+				pr, pw := io.Pipe()
+				go func() {
+					_, err := io.Copy(pw, bytes.NewReader(tt.body))
+					pw.CloseWithError(err)
+				}()
+				reqBody = pr
+			}
+
+			res, err := client.Post(server.URL, "text/plain", reqBody)
+			if err != nil {
+				t.Fatalf("client post error: %v", err)
+			}
+			defer res.Body.Close()
+
+			got, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("error reading response body: %v", err)
+			}
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tt.wantStatus, got)
+			}
+			if string(got) != tt.wantBody {
+				t.Fatalf("body = %q, want %q", got, tt.wantBody)
+			}
+			if len(gotTransferEncoding) != 0 {
+				t.Fatalf("expected the request to be sent with a Content-Length, got Transfer-Encoding %v", gotTransferEncoding)
+			}
+		})
+	}
+}
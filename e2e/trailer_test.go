@@ -0,0 +1,179 @@
+package e2e
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/sigparams"
+)
+
+func TestE2E_TrailerContentDigest(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:       "foo",
+		Scheme:    "http",
+		Authority: strings.TrimPrefix(server.URL, "http://"),
+		Validation: &sigparams.ValidateOpts{
+			BeforeDuration: time.Minute,
+			RequiredCoveredComponents: map[string]bool{
+				"@method":           true,
+				"@target-uri":       true,
+				"content-digest;tr": true,
+			},
+			RequireNonce: true,
+		},
+	})
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// echo the verified body back to the client.
+		_, _ = io.Copy(w, r.Body)
+	})))
+
+	client := &http.Client{
+		Transport: &signer.Transport{
+			Tag:               "foo",
+			Alg:               alg_ecdsa.NewP256Signer(key),
+			CoveredComponents: []string{"@method", "@target-uri", "content-digest;tr"},
+		},
+	}
+
+	// use a reader without a known length, so that the body is streamed.
+	body := io.MultiReader(strings.NewReader("hello, "), strings.NewReader("world!"))
+
+	res, err := client.Post(server.URL, "text/plain", body)
+	if err != nil {
+		t.Fatalf("client post error: %v", err)
+	}
+	defer res.Body.Close()
+
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d: %s", res.StatusCode, got)
+	}
+
+	if string(got) != "hello, world!" {
+		t.Fatalf("response not as expected: got %s", got)
+	}
+
+	// a request without a body cannot be signed using trailers.
+	_, err = client.Get(server.URL)
+	if err == nil {
+		t.Fatal("expected an error signing a request without a body using trailers")
+	}
+}
+
+func TestE2E_TrailerSpillThreshold(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	dir := t.TempDir()
+
+	countTempFiles := func() int {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	verify := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_ecdsa.StaticKeyDirectory{
+			Key: &key.PublicKey,
+		},
+		Tag:       "foo",
+		Scheme:    "http",
+		Authority: strings.TrimPrefix(server.URL, "http://"),
+		Validation: &sigparams.ValidateOpts{
+			BeforeDuration: time.Minute,
+			RequiredCoveredComponents: map[string]bool{
+				"@method":           true,
+				"@target-uri":       true,
+				"content-digest;tr": true,
+			},
+			RequireNonce: true,
+		},
+		SpillThreshold: 4,
+		TempDir:        dir,
+	})
+
+	var tempFilesInHandler int
+
+	mux.Handle("/", verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tempFilesInHandler = countTempFiles()
+		_, _ = io.Copy(w, r.Body)
+	})))
+
+	client := &http.Client{
+		Transport: &signer.Transport{
+			Tag:               "foo",
+			Alg:               alg_ecdsa.NewP256Signer(key),
+			CoveredComponents: []string{"@method", "@target-uri", "content-digest;tr"},
+		},
+	}
+
+	body := io.MultiReader(strings.NewReader("hello, "), strings.NewReader("world!"))
+
+	res, err := client.Post(server.URL, "text/plain", body)
+	if err != nil {
+		t.Fatalf("client post error: %v", err)
+	}
+	defer res.Body.Close()
+
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d: %s", res.StatusCode, got)
+	}
+
+	if string(got) != "hello, world!" {
+		t.Fatalf("response not as expected: got %s", got)
+	}
+
+	// the body was written to a temporary file rather than held in memory.
+	if tempFilesInHandler != 1 {
+		t.Fatalf("temporary files during handler = %d, want 1", tempFilesInHandler)
+	}
+
+	if n := countTempFiles(); n != 0 {
+		t.Fatalf("temporary files after request = %d, want 0", n)
+	}
+}
@@ -0,0 +1,166 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// getCurrentTime allows the current time to be overridden for testing.
+var getCurrentTime = time.Now
+
+// DefaultNonceTTL is the default duration that nonces are remembered for.
+// It matches the BeforeDuration in httpsig.DefaultValidationOpts().
+const DefaultNonceTTL = time.Minute
+
+// ErrNonceStorageFull is returned by ExpiringNonce.Seen if the
+// maximum number of nonces are being tracked.
+var ErrNonceStorageFull = errors.New("nonce storage is full")
+
+type ExpiringNonceOpts struct {
+	// TTL is the minimum duration that a nonce is remembered for.
+	//
+	// Signatures older than ValidateOpts.BeforeDuration are rejected by the
+	// verifier, so nonces only need to be remembered for that long.
+	// TTL should be at least BeforeDuration + AfterDuration.
+	//
+	// If zero, DefaultNonceTTL is used.
+	TTL time.Duration
+
+	// MaxEntries, if non-zero, is the maximum number of nonces to track.
+	//
+	// If the limit is reached, Seen returns ErrNonceStorageFull rather than
+	// forgetting nonces early, which would allow requests to be replayed.
+	MaxEntries int
+
+	// DisableJanitor disables the background goroutine which
+	// removes expired nonces. Expired nonces are still removed
+	// when Seen is called.
+	DisableJanitor bool
+}
+
+// ExpiringNonce tracks seen nonces in memory, forgetting
+// nonces once they are older than the TTL.
+//
+// Nonces are stored in two generations. Every TTL the current generation
+// becomes the previous generation, and the previous generation is discarded,
+// so nonces are remembered for between TTL and 2*TTL.
+//
+// Nonces are not persisted across restarts or shared between instances, so
+// this is only suitable for single-instance deployments.
+type ExpiringNonce struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu       sync.Mutex
+	current  map[string]struct{}
+	previous map[string]struct{}
+	// rotatedAt is the time that the current generation started.
+	rotatedAt time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewExpiringNonceStorage creates an ExpiringNonce and
+// starts the background janitor, unless it is disabled.
+//
+// Call Close to stop the janitor.
+func NewExpiringNonceStorage(opts ExpiringNonceOpts) *ExpiringNonce {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultNonceTTL
+	}
+
+	m := &ExpiringNonce{
+		ttl:        ttl,
+		maxEntries: opts.MaxEntries,
+		current:    map[string]struct{}{},
+		previous:   map[string]struct{}{},
+		rotatedAt:  getCurrentTime(),
+		stop:       make(chan struct{}),
+	}
+
+	if !opts.DisableJanitor {
+		go m.janitor()
+	}
+
+	return m
+}
+
+func (m *ExpiringNonce) Seen(ctx context.Context, nonce string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rotate(getCurrentTime())
+
+	if _, ok := m.current[nonce]; ok {
+		return true, nil
+	}
+	if _, ok := m.previous[nonce]; ok {
+		return true, nil
+	}
+
+	if m.maxEntries > 0 && len(m.current)+len(m.previous) >= m.maxEntries {
+		return false, ErrNonceStorageFull
+	}
+
+	m.current[nonce] = struct{}{}
+	return false, nil
+}
+
+// Len returns the number of nonces being tracked.
+func (m *ExpiringNonce) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rotate(getCurrentTime())
+
+	return len(m.current) + len(m.previous)
+}
+
+// Close stops the background janitor.
+func (m *ExpiringNonce) Close() error {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	return nil
+}
+
+// rotate discards generations which are older than the TTL.
+// The caller must hold m.mu.
+func (m *ExpiringNonce) rotate(now time.Time) {
+	elapsed := now.Sub(m.rotatedAt)
+	if elapsed < m.ttl {
+		return
+	}
+
+	if elapsed < 2*m.ttl {
+		m.previous = m.current
+	} else {
+		// both generations have expired.
+		m.previous = map[string]struct{}{}
+	}
+
+	m.current = map[string]struct{}{}
+	m.rotatedAt = now
+}
+
+// janitor periodically discards expired nonces
+// until the storage is closed.
+func (m *ExpiringNonce) janitor() {
+	ticker := time.NewTicker(m.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			m.rotate(getCurrentTime())
+			m.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExpiringNonce(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	getCurrentTime = func() time.Time { return now }
+	defer func() { getCurrentTime = time.Now }()
+
+	m := NewExpiringNonceStorage(ExpiringNonceOpts{
+		TTL:            time.Minute,
+		DisableJanitor: true,
+	})
+
+	seen := func(nonce string) bool {
+		t.Helper()
+		got, err := m.Seen(ctx, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if seen("first") {
+		t.Fatal("expected first nonce not to be seen")
+	}
+	if !seen("first") {
+		t.Fatal("expected first nonce to be seen")
+	}
+
+	// the nonce is remembered for at least the TTL.
+	now = now.Add(59 * time.Second)
+	if !seen("first") {
+		t.Fatal("expected first nonce to be seen before the TTL")
+	}
+
+	if seen("second") {
+		t.Fatal("expected second nonce not to be seen")
+	}
+
+	// the first generation has rotated, but the nonce is still remembered.
+	now = now.Add(30 * time.Second)
+	if !seen("first") {
+		t.Fatal("expected first nonce to be remembered in the previous generation")
+	}
+
+	// both generations have expired.
+	now = now.Add(2 * time.Minute)
+	if got := m.Len(); got != 0 {
+		t.Fatalf("Len() = %d, want 0", got)
+	}
+	if seen("first") {
+		t.Fatal("expected first nonce to be forgotten after it expired")
+	}
+}
+
+func TestExpiringNonce_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+
+	m := NewExpiringNonceStorage(ExpiringNonceOpts{
+		MaxEntries:     2,
+		DisableJanitor: true,
+	})
+
+	for _, nonce := range []string{"a", "b"} {
+		_, err := m.Seen(ctx, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// existing nonces are still reported as seen.
+	got, err := m.Seen(ctx, "a")
+	if err != nil || !got {
+		t.Fatalf("Seen() = %v, %v, want true, nil", got, err)
+	}
+
+	_, err = m.Seen(ctx, "c")
+	if !errors.Is(err, ErrNonceStorageFull) {
+		t.Fatalf("expected ErrNonceStorageFull, got %v", err)
+	}
+}
+
+func TestExpiringNonce_Janitor(t *testing.T) {
+	m := NewExpiringNonceStorage(ExpiringNonceOpts{
+		TTL: 10 * time.Millisecond,
+	})
+	defer m.Close()
+
+	_, err := m.Seen(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		m.mu.Lock()
+		n := len(m.current) + len(m.previous)
+		m.mu.Unlock()
+
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the janitor to remove the expired nonce")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Close is idempotent.
+	_ = m.Close()
+}
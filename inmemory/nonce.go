@@ -8,7 +8,8 @@ import (
 // Nonce tracks seen nonces in memory.
 //
 // It is not recommended to use this in production because
-// this will not persist across restarts.
+// this will not persist across restarts, and nonces are never
+// removed. ExpiringNonce removes nonces once they have expired.
 type Nonce struct {
 	mu     sync.Mutex
 	nonces map[string]bool
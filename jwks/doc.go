@@ -0,0 +1,8 @@
+/*
+Package jwks provides a verifier.KeyDirectory which loads verification keys
+from a JSON Web Key Set (JWKS), as described in https://www.rfc-editor.org/rfc/rfc7517.html.
+
+Keys are looked up by their 'kid' parameter, and the verification algorithm is
+chosen based on the 'kty', 'crv' and 'alg' parameters of the key.
+*/
+package jwks
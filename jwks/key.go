@@ -0,0 +1,248 @@
+package jwks
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/alg_ed25519"
+	"github.com/common-fate/httpsig/alg_hmac"
+	"github.com/common-fate/httpsig/alg_rsa"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// KeySet is a JSON Web Key Set.
+//
+// See: https://www.rfc-editor.org/rfc/rfc7517.html#section-5
+type KeySet struct {
+	Keys []Key `json:"keys"`
+}
+
+// Key is a JSON Web Key.
+//
+// Only the parameters required to construct a verification
+// algorithm are included.
+//
+// See: https://www.rfc-editor.org/rfc/rfc7517.html#section-4
+type Key struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// Curve is the curve for EC and OKP keys.
+	Curve string `json:"crv,omitempty"`
+
+	// X and Y are the public key coordinates for EC and OKP keys.
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+
+	// N and E are the modulus and exponent for RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// K is the key value for symmetric keys.
+	K string `json:"k,omitempty"`
+}
+
+// Parse a JSON Web Key Set.
+func Parse(data []byte) (*KeySet, error) {
+	var ks KeySet
+
+	err := json.Unmarshal(data, &ks)
+	if err != nil {
+		return nil, fmt.Errorf("parsing JSON Web Key Set: %w", err)
+	}
+
+	return &ks, nil
+}
+
+// algorithms maps JSON Web Algorithm names to the
+// equivalent HTTP Message Signature algorithm names.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-3.3.7
+var algorithms = map[string]string{
+	"ES256": alg_ecdsa.P256_SHA256,
+	"ES384": alg_ecdsa.P384_SHA384,
+	"EdDSA": alg_ed25519.Ed25519Alg,
+	"RS256": alg_rsa.RSASSA_PKCS1_1_5_SHA256,
+	"PS512": alg_rsa.RSASSA_PSS_SHA512,
+	"HS256": alg_hmac.HMAC_SHA256,
+}
+
+// Verifier returns the verification algorithm for the key.
+//
+// The algorithm is chosen based on the 'kty', 'crv' and 'alg' parameters of the key.
+// If the key does not specify an algorithm and more than one algorithm is possible
+// for the key type, such as for RSA keys, clientSpecifiedAlg is used.
+//
+// attributes are the server-side attributes associated with the key.
+//
+// Symmetric ('oct') keys are supported, but are ignored by KeyDirectory
+// unless KeyDirectory.AllowSymmetricKeys is set.
+func (k Key) Verifier(clientSpecifiedAlg string, attributes any) (verifier.Algorithm, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("%w: key %q cannot be used for signatures: use was %q", verifier.ErrKeyNotFound, k.KeyID, k.Use)
+	}
+
+	alg := k.Algorithm
+	if mapped, ok := algorithms[alg]; ok {
+		alg = mapped
+	}
+
+	switch k.KeyType {
+	case "EC":
+		return k.ecdsaVerifier(alg, attributes)
+
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Curve)
+		}
+		if err := checkAlg(alg, alg_ed25519.Ed25519Alg); err != nil {
+			return nil, err
+		}
+		x, err := decode("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length %d", len(x))
+		}
+		return alg_ed25519.Ed25519{PublicKey: ed25519.PublicKey(x), Attrs: attributes}, nil
+
+	case "RSA":
+		pub, err := k.rsaPublicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if alg == "" {
+			alg = clientSpecifiedAlg
+		}
+
+		switch alg {
+		case alg_rsa.RSASSA_PKCS1_1_5_SHA256:
+			return alg_rsa.RSAPKCS256{PublicKey: pub, Attrs: attributes}, nil
+		case alg_rsa.RSASSA_PSS_SHA512:
+			return alg_rsa.RSAPSS512{PublicKey: pub, Attrs: attributes}, nil
+		case "":
+			return nil, errors.New("the algorithm for the RSA key could not be determined: set 'alg' on the key")
+		}
+		return nil, fmt.Errorf("%w: unsupported algorithm %q for RSA key", verifier.ErrAlgorithmMismatch, alg)
+
+	case "oct":
+		if err := checkAlg(alg, alg_hmac.HMAC_SHA256); err != nil {
+			return nil, err
+		}
+		secret, err := decode("k", k.K)
+		if err != nil {
+			return nil, err
+		}
+		return alg_hmac.NewHMACWithAttributes(secret, attributes), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+func (k Key) ecdsaVerifier(alg string, attributes any) (verifier.Algorithm, error) {
+	var (
+		curve     elliptic.Curve
+		ecdhCurve ecdh.Curve
+		want      string
+	)
+
+	switch k.Curve {
+	case "P-256":
+		curve, ecdhCurve, want = elliptic.P256(), ecdh.P256(), alg_ecdsa.P256_SHA256
+	case "P-384":
+		curve, ecdhCurve, want = elliptic.P384(), ecdh.P384(), alg_ecdsa.P384_SHA384
+	default:
+		return nil, fmt.Errorf("unsupported EC curve %q", k.Curve)
+	}
+
+	if err := checkAlg(alg, want); err != nil {
+		return nil, err
+	}
+
+	x, err := decode("x", k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decode("y", k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("invalid %s public key coordinate length", k.Curve)
+	}
+
+	// validate that the point is on the curve, using the
+	// uncompressed point encoding.
+	point := append([]byte{4}, append(x, y...)...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid %s public key: %w", k.Curve, err)
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+
+	if want == alg_ecdsa.P384_SHA384 {
+		return alg_ecdsa.P384{PublicKey: pub, Attrs: attributes}, nil
+	}
+	return alg_ecdsa.P256{PublicKey: pub, Attrs: attributes}, nil
+}
+
+func (k Key) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decode("n", k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decode("e", k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+		return nil, errors.New("invalid RSA public exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
+
+// checkAlg returns an error if the 'alg' parameter
+// of the key is set and does not match the expected algorithm.
+func checkAlg(alg string, want string) error {
+	if alg != "" && alg != want {
+		return fmt.Errorf("algorithm %q does not match the key type, expected %q", alg, want)
+	}
+	return nil
+}
+
+// decode decodes a base64url-encoded key parameter.
+func decode(param string, val string) ([]byte, error) {
+	if val == "" {
+		return nil, fmt.Errorf("key parameter %q was empty", param)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(val)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key parameter %q: %w", param, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,332 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// getCurrentTime allows the current time to be overridden for testing.
+var getCurrentTime = time.Now
+
+const (
+	// DefaultMaxAge is the duration that keys are cached for
+	// if the JWKS endpoint does not specify a max-age.
+	DefaultMaxAge = time.Hour
+
+	// DefaultMinRefreshInterval is the minimum interval between
+	// fetching the key set when a key ID is not found.
+	DefaultMinRefreshInterval = time.Minute
+
+	// maxKeySetBytes is the maximum size of a key set.
+	maxKeySetBytes = 1 << 20 // 1 MB
+)
+
+// FetchFunc fetches a JSON Web Key Set.
+//
+// maxAge is the duration that the key set may be cached for.
+// If maxAge is negative, the KeyDirectory's DefaultMaxAge is used.
+type FetchFunc func(ctx context.Context) (data []byte, maxAge time.Duration, err error)
+
+// KeyDirectory implements the verifier.KeyDirectory interface
+// by looking up keys in a JSON Web Key Set.
+//
+// The key set is cached, and is fetched again once the cache expires.
+// If a key ID is not found in the cached key set, the key set is fetched
+// again to pick up any newly published keys. Once keys have been fetched,
+// the key set is fetched at most once per MinRefreshInterval.
+//
+// Concurrent lookups share a single fetch of the key set, and lookups
+// of cached keys are not blocked while the key set is being fetched.
+type KeyDirectory struct {
+	// Fetch fetches the key set.
+	Fetch FetchFunc
+
+	// DefaultMaxAge is the duration that keys are cached for if
+	// the key set does not specify a max-age.
+	//
+	// If zero, DefaultMaxAge is used.
+	DefaultMaxAge time.Duration
+
+	// MinRefreshInterval is the minimum interval between fetching
+	// the key set when a key ID is not found.
+	//
+	// If zero, DefaultMinRefreshInterval is used.
+	MinRefreshInterval time.Duration
+
+	// Attributes, if set, returns the server-side attributes
+	// associated with a key.
+	Attributes func(key Key) any
+
+	// AllowSymmetricKeys, if true, allows symmetric ('oct') keys to be
+	// used, which verify HMAC signatures.
+	//
+	// Symmetric keys are secrets which can be used to create signatures as
+	// well as verify them, so they must not be published in a key set.
+	// Only set AllowSymmetricKeys if the key set is loaded from a trusted,
+	// private location, such as a local file.
+	AllowSymmetricKeys bool
+
+	mu        sync.Mutex
+	keys      map[string]Key
+	expiresAt time.Time
+	fetchedAt time.Time
+	fetching  *fetchCall
+}
+
+var _ verifier.KeyDirectory = &KeyDirectory{}
+
+// NewRemoteKeyDirectory returns a KeyDirectory which fetches the
+// key set from a URL, such as 'https://example.com/.well-known/jwks.json'.
+//
+// The key set is cached based on the Cache-Control header of the response.
+// If client is nil, http.DefaultClient is used.
+func NewRemoteKeyDirectory(url string, client *http.Client) *KeyDirectory {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &KeyDirectory{
+		Fetch: func(ctx context.Context) ([]byte, time.Duration, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			req.Header.Set("Accept", "application/jwk-set+json, application/json")
+
+			res, err := client.Do(req)
+			if err != nil {
+				return nil, 0, fmt.Errorf("fetching JSON Web Key Set: %w", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != http.StatusOK {
+				return nil, 0, fmt.Errorf("fetching JSON Web Key Set: unexpected status %d", res.StatusCode)
+			}
+
+			data, err := io.ReadAll(io.LimitReader(res.Body, maxKeySetBytes))
+			if err != nil {
+				return nil, 0, fmt.Errorf("reading JSON Web Key Set: %w", err)
+			}
+
+			return data, cacheMaxAge(res.Header), nil
+		},
+	}
+}
+
+// NewFileKeyDirectory returns a KeyDirectory which reads the
+// key set from a file.
+//
+// The file is read again when the cache expires, or when a key ID is not found.
+// To use symmetric keys from the file, set AllowSymmetricKeys on the returned directory.
+func NewFileKeyDirectory(path string) *KeyDirectory {
+	return &KeyDirectory{
+		Fetch: func(ctx context.Context) ([]byte, time.Duration, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, 0, fmt.Errorf("reading JSON Web Key Set: %w", err)
+			}
+			return data, -1, nil
+		},
+	}
+}
+
+// GetKey looks up a key in the key set based on the key ID.
+//
+// If the key is not found, an error wrapping verifier.ErrKeyNotFound is returned.
+func (d *KeyDirectory) GetKey(ctx context.Context, kid string, clientSpecifiedAlg string) (verifier.Algorithm, error) {
+	key, err := d.lookup(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes any
+	if d.Attributes != nil {
+		attributes = d.Attributes(key)
+	}
+
+	return key.Verifier(clientSpecifiedAlg, attributes)
+}
+
+// lookup finds a key in the cached key set,
+// fetching the key set if required.
+func (d *KeyDirectory) lookup(ctx context.Context, kid string) (Key, error) {
+	now := getCurrentTime()
+
+	// continue to use the cached keys if the key set
+	// could not be fetched.
+	err := d.refresh(ctx, now, false)
+
+	key, ok, cached := d.cachedKey(kid)
+	if !cached {
+		return Key{}, err
+	}
+	if ok {
+		return key, nil
+	}
+
+	// the key may have been recently published, so fetch the
+	// key set again, limited to once per refresh interval.
+	err = d.refresh(ctx, now, true)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key, ok, _ = d.cachedKey(kid)
+	if ok {
+		return key, nil
+	}
+
+	return Key{}, fmt.Errorf("%w: %q", verifier.ErrKeyNotFound, kid)
+}
+
+// cachedKey returns the key from the cached key set, and
+// whether the key set has been fetched.
+func (d *KeyDirectory) cachedKey(kid string) (key Key, ok bool, cached bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, ok = d.keys[kid]
+	return key, ok, d.keys != nil
+}
+
+// fetchCall is an in-progress fetch of the key set.
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+// refresh fetches the key set and updates the cache, if the key set has
+// not been fetched or the cache has expired. If unknownKID is true, the key
+// set is fetched if it has not been fetched within the refresh interval.
+//
+// The lock is not held while the key set is fetched, so that lookups of
+// cached keys are not blocked by a slow fetch, and concurrent callers share
+// a single fetch. Callers wait for a fetch which is already in progress if
+// the key set has not been fetched, or if unknownKID is true.
+func (d *KeyDirectory) refresh(ctx context.Context, now time.Time, unknownKID bool) error {
+	if d.Fetch == nil {
+		return errors.New("jwks: Fetch must be defined")
+	}
+
+	d.mu.Lock()
+
+	if call := d.fetching; call != nil {
+		wait := d.keys == nil || unknownKID
+		d.mu.Unlock()
+
+		if !wait {
+			return nil
+		}
+
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	canRefresh := now.Sub(d.fetchedAt) >= d.minRefreshInterval()
+	due := d.keys == nil || (canRefresh && (unknownKID || now.After(d.expiresAt)))
+	if !due {
+		d.mu.Unlock()
+		return nil
+	}
+
+	call := &fetchCall{done: make(chan struct{})}
+	d.fetching = call
+	d.fetchedAt = now
+	d.mu.Unlock()
+
+	keys, maxAge, err := d.fetch(ctx)
+
+	d.mu.Lock()
+	if err == nil {
+		d.keys = keys
+		d.expiresAt = now.Add(maxAge)
+	}
+	d.fetching = nil
+	d.mu.Unlock()
+
+	call.err = err
+	close(call.done)
+
+	return err
+}
+
+// fetch fetches and parses the key set, returning the
+// keys by key ID and the duration to cache them for.
+func (d *KeyDirectory) fetch(ctx context.Context) (map[string]Key, time.Duration, error) {
+	data, maxAge, err := d.Fetch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ks, err := Parse(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keys := make(map[string]Key, len(ks.Keys))
+	for _, k := range ks.Keys {
+		// keys without an ID can't be looked up.
+		if k.KeyID == "" {
+			continue
+		}
+		// symmetric keys are ignored unless they have been explicitly allowed.
+		if k.KeyType == "oct" && !d.AllowSymmetricKeys {
+			continue
+		}
+		keys[k.KeyID] = k
+	}
+
+	if maxAge < 0 {
+		maxAge = d.DefaultMaxAge
+		if maxAge == 0 {
+			maxAge = DefaultMaxAge
+		}
+	}
+
+	return keys, maxAge, nil
+}
+
+func (d *KeyDirectory) minRefreshInterval() time.Duration {
+	if d.MinRefreshInterval == 0 {
+		return DefaultMinRefreshInterval
+	}
+	return d.MinRefreshInterval
+}
+
+// cacheMaxAge returns the max-age directive from the Cache-Control header.
+//
+// If the response may not be cached, zero is returned.
+// If the header does not specify a max-age, -1 is returned.
+func cacheMaxAge(h http.Header) time.Duration {
+	maxAge := time.Duration(-1)
+
+	for _, directive := range strings.Split(strings.Join(h.Values("Cache-Control"), ","), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return 0
+		case "max-age":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err == nil && seconds >= 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	return maxAge
+}
@@ -0,0 +1,258 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+func TestRemoteKeyDirectory(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	getCurrentTime = func() time.Time { return now }
+	defer func() { getCurrentTime = time.Now }()
+
+	first, _ := ecKey(t, "first")
+	second, _ := ecKey(t, "second")
+
+	keys := []Key{first}
+	var fetches atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_ = json.NewEncoder(w).Encode(KeySet{Keys: keys})
+	}))
+	defer server.Close()
+
+	d := NewRemoteKeyDirectory(server.URL, server.Client())
+	d.Attributes = func(key Key) any { return key.KeyID }
+
+	alg, err := d.GetKey(ctx, "first", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := alg.(interface{ Attributes() any }).Attributes(); got != "first" {
+		t.Errorf("Attributes() = %v, want first", got)
+	}
+
+	// the key set is cached.
+	_, err = d.GetKey(ctx, "first", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("fetches = %d, want 1", got)
+	}
+
+	// an unknown key ID causes the key set to be fetched again,
+	// but only once per refresh interval.
+	now = now.Add(DefaultMinRefreshInterval)
+
+	_, err = d.GetKey(ctx, "second", "")
+	if !errors.Is(err, verifier.ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	_, err = d.GetKey(ctx, "second", "")
+	if !errors.Is(err, verifier.ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want 2", got)
+	}
+
+	// a newly published key is found after the refresh interval.
+	keys = []Key{first, second}
+	now = now.Add(DefaultMinRefreshInterval)
+
+	_, err = d.GetKey(ctx, "second", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fetches.Load(); got != 3 {
+		t.Fatalf("fetches = %d, want 3", got)
+	}
+
+	// a removed key is no longer found once the max-age has passed.
+	keys = []Key{second}
+	now = now.Add(6 * time.Minute)
+
+	_, err = d.GetKey(ctx, "first", "")
+	if !errors.Is(err, verifier.ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	if got := fetches.Load(); got != 4 {
+		t.Fatalf("fetches = %d, want 4", got)
+	}
+}
+
+func TestKeyDirectory_ConcurrentFetch(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	getCurrentTime = func() time.Time { return now }
+	defer func() { getCurrentTime = time.Now }()
+
+	key, _ := ecKey(t, "first")
+	data, err := json.Marshal(KeySet{Keys: []Key{key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+
+	d := &KeyDirectory{
+		Fetch: func(ctx context.Context) ([]byte, time.Duration, error) {
+			// the first fetch completes immediately, and later fetches
+			// block until the test unblocks them.
+			if fetches.Add(1) > 1 {
+				close(started)
+				<-unblock
+			}
+			return data, time.Minute, nil
+		},
+	}
+
+	_, err = d.GetKey(ctx, "first", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// expire the cache, so that the next lookup fetches the key set.
+	now = now.Add(2 * time.Minute)
+
+	refreshErr := make(chan error)
+	go func() {
+		_, err := d.GetKey(ctx, "first", "")
+		refreshErr <- err
+	}()
+
+	<-started
+
+	// cached keys can be looked up while the key set is being fetched.
+	_, err = d.GetKey(ctx, "first", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lookups of unknown keys share the fetch which is in progress.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.GetKey(ctx, "unknown", "")
+			if !errors.Is(err, verifier.ErrKeyNotFound) {
+				t.Errorf("expected ErrKeyNotFound, got %v", err)
+			}
+		}()
+	}
+
+	close(unblock)
+	wg.Wait()
+
+	err = <-refreshErr
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want 2", got)
+	}
+}
+
+func TestFileKeyDirectory(t *testing.T) {
+	key, _ := ecKey(t, "file")
+
+	data, err := json.Marshal(KeySet{Keys: []Key{key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewFileKeyDirectory(path)
+
+	_, err = d.GetKey(context.Background(), "file", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyDirectory_SymmetricKeys(t *testing.T) {
+	ctx := context.Background()
+
+	secret := Key{
+		KeyType:   "oct",
+		KeyID:     "secret",
+		Algorithm: "HS256",
+		K:         b64([]byte("hmac-secret")),
+	}
+
+	data, err := json.Marshal(KeySet{Keys: []Key{secret}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetch := func(ctx context.Context) ([]byte, time.Duration, error) {
+		return data, -1, nil
+	}
+
+	// symmetric keys are ignored by default.
+	d := &KeyDirectory{Fetch: fetch}
+
+	_, err = d.GetKey(ctx, "secret", "")
+	if !errors.Is(err, verifier.ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	d = &KeyDirectory{Fetch: fetch, AllowSymmetricKeys: true}
+
+	alg, err := d.GetKey(ctx, "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alg.Type() != "hmac-sha256" {
+		t.Fatalf("Type() = %q, want hmac-sha256", alg.Type())
+	}
+}
+
+func Test_cacheMaxAge(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl []string
+		want         time.Duration
+	}{
+		{name: "max_age", cacheControl: []string{"public, max-age=60"}, want: time.Minute},
+		{name: "multiple_fields", cacheControl: []string{"public", "max-age=60"}, want: time.Minute},
+		{name: "no_store", cacheControl: []string{"no-store"}, want: 0},
+		{name: "no_cache", cacheControl: []string{"max-age=60, no-cache"}, want: 0},
+		{name: "missing", want: -1},
+		{name: "invalid", cacheControl: []string{"max-age=foo"}, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{"Cache-Control": tt.cacheControl}
+			if got := cacheMaxAge(h); got != tt.want {
+				t.Errorf("cacheMaxAge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
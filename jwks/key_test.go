@@ -0,0 +1,183 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/common-fate/httpsig/alg_ecdsa"
+	"github.com/common-fate/httpsig/alg_ed25519"
+	"github.com/common-fate/httpsig/alg_rsa"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func ecKey(t *testing.T, kid string) (Key, *ecdsa.PrivateKey) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Key{
+		KeyType: "EC",
+		KeyID:   kid,
+		Curve:   "P-256",
+		X:       b64(priv.X.FillBytes(make([]byte, 32))),
+		Y:       b64(priv.Y.FillBytes(make([]byte, 32))),
+	}, priv
+}
+
+func TestKey_Verifier(t *testing.T) {
+	ctx := context.Background()
+
+	ecJWK, ecPriv := ecKey(t, "ec")
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaJWK := Key{
+		KeyType: "RSA",
+		N:       b64(rsaPriv.N.Bytes()),
+		E:       b64(big.NewInt(int64(rsaPriv.E)).Bytes()),
+	}
+
+	tests := []struct {
+		name               string
+		key                Key
+		clientSpecifiedAlg string
+		signer             signer.Algorithm
+		wantType           string
+		wantErr            bool
+		wantIs             error
+	}{
+		{
+			name:     "ecdsa_p256",
+			key:      ecJWK,
+			signer:   alg_ecdsa.NewP256Signer(ecPriv),
+			wantType: alg_ecdsa.P256_SHA256,
+		},
+		{
+			name: "ecdsa_p256_with_jose_alg",
+			key: func() Key {
+				k := ecJWK
+				k.Algorithm = "ES256"
+				return k
+			}(),
+			signer:   alg_ecdsa.NewP256Signer(ecPriv),
+			wantType: alg_ecdsa.P256_SHA256,
+		},
+		{
+			name: "ecdsa_alg_mismatch",
+			key: func() Key {
+				k := ecJWK
+				k.Algorithm = "ES384"
+				return k
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "ecdsa_point_not_on_curve",
+			key: func() Key {
+				k := ecJWK
+				k.Y = k.X
+				return k
+			}(),
+			wantErr: true,
+		},
+		{
+			name:     "ed25519",
+			key:      Key{KeyType: "OKP", Curve: "Ed25519", X: b64(edPub)},
+			signer:   alg_ed25519.Ed25519{PrivateKey: edPriv},
+			wantType: alg_ed25519.Ed25519Alg,
+		},
+		{
+			name: "rsa_pss",
+			key: func() Key {
+				k := rsaJWK
+				k.Algorithm = "PS512"
+				return k
+			}(),
+			signer:   alg_rsa.NewRSAPSS512Signer(rsaPriv),
+			wantType: alg_rsa.RSASSA_PSS_SHA512,
+		},
+		{
+			name:               "rsa_client_specified_alg",
+			key:                rsaJWK,
+			clientSpecifiedAlg: alg_rsa.RSASSA_PKCS1_1_5_SHA256,
+			signer:             alg_rsa.NewRSAPKCS256Signer(rsaPriv),
+			wantType:           alg_rsa.RSASSA_PKCS1_1_5_SHA256,
+		},
+		{
+			name:               "rsa_unsupported_client_specified_alg",
+			key:                rsaJWK,
+			clientSpecifiedAlg: alg_ecdsa.P256_SHA256,
+			wantErr:            true,
+			wantIs:             verifier.ErrAlgorithmMismatch,
+		},
+		{
+			name:    "rsa_without_alg",
+			key:     rsaJWK,
+			wantErr: true,
+		},
+		{
+			name: "encryption_key",
+			key: func() Key {
+				k := ecJWK
+				k.Use = "enc"
+				return k
+			}(),
+			wantErr: true,
+			wantIs:  verifier.ErrKeyNotFound,
+		},
+		{
+			name:    "unsupported_key_type",
+			key:     Key{KeyType: "foo"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.key.Verifier(tt.clientSpecifiedAlg, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Key.Verifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Fatalf("expected errors.Is(%s, %s)", err, tt.wantIs)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Type() != tt.wantType {
+				t.Errorf("Type() = %v, want %v", got.Type(), tt.wantType)
+			}
+
+			// the verifier should verify a signature from the matching private key.
+			sig, err := tt.signer.Sign(ctx, "example")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = got.Verify(ctx, "example", sig)
+			if err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		})
+	}
+}
@@ -3,10 +3,15 @@ package httpsig
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/common-fate/httpsig/contentdigest"
 	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
 	"github.com/common-fate/httpsig/verifier"
 )
 
@@ -27,6 +32,18 @@ type MiddlewareOpts struct {
 	// incoming requests must have only one signature matching the tag.
 	Tag string
 
+	// Chain, if set, is the tags of earlier signatures which must be
+	// covered by the signature matching Tag, such as a client signature
+	// which has been countersigned by a proxy. See verifier.Verifier.Chain.
+	Chain []string
+
+	// Policies, if set, are the requirements for signatures with different
+	// tags, and PolicyMatch determines whether all or any of them must be
+	// satisfied. Tag and Chain are ignored if Policies is set.
+	// See verifier.Verifier.Policies.
+	Policies    []verifier.Policy
+	PolicyMatch verifier.PolicyMatch
+
 	// Validation overrides the validation options.
 	//
 	// If nil, http.DefaultValidationOpts() is used.
@@ -42,10 +59,81 @@ type MiddlewareOpts struct {
 	// that the verifier is running on.
 	Authority string
 
+	// StreamBody, if true, verifies the request body as it is read by the
+	// handler rather than reading it into memory before calling the handler.
+	// This allows large signed request bodies to be accepted.
+	//
+	// The body returns contentdigest.ErrDigestMismatch instead of io.EOF if
+	// it does not match the signed Content-Digest header, so handlers must
+	// read the body to EOF and check the error before acting on it.
+	StreamBody bool
+
+	// SpillThreshold, if non-zero, is the number of bytes of the request body
+	// to hold in memory when verifying the Content-Digest. Larger bodies are
+	// written to a temporary file in TempDir, which is removed once the
+	// request has been handled.
+	SpillThreshold int64
+
+	// TempDir is the directory to write temporary files to.
+	//
+	// If empty, the default directory for temporary files is used.
+	TempDir string
+
+	// MaxBodyBytes, if non-zero, is the maximum size of the request body
+	// when verifying the Content-Digest. If zero, the limit of the key's
+	// content digester is used, which is 10MB by default.
+	//
+	// Larger requests are rejected with a 413 Request Entity Too Large status.
+	MaxBodyBytes int64
+
+	// WantContentDigest and WantReprDigest, if set, are the digest algorithms
+	// preferred by the server. They are sent in the Want-Content-Digest and
+	// Want-Repr-Digest fields of every response, so that clients can select
+	// a digest algorithm for subsequent requests, such as by setting
+	// ContentDigestNegotiator on signer.Transport.
+	//
+	// If the preferences are invalid, all requests are rejected
+	// and the error is passed to OnValidationError once.
+	//
+	// See: https://www.rfc-editor.org/rfc/rfc9530.html#section-4
+	WantContentDigest []contentdigest.Preference
+	WantReprDigest    []contentdigest.Preference
+
 	// OnValidationError, if set, is called when there is a validation error
 	// with the request context.
 	OnValidationError func(ctx context.Context, err error)
 
+	// SendAcceptSignature, if true, includes an Accept-Signature field
+	// in the response when a request is rejected. The field describes the
+	// signatures required by the server, based on Tag or Policies and the RequiredCoveredComponents
+	// and RequireNonce validation options.
+	//
+	// Clients can use this to re-sign the request, such as by enabling
+	// RetryOnAcceptSignature on signer.Transport.
+	//
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-5.1
+	SendAcceptSignature bool
+
+	// AcceptSignatureAlg, if set, is the signing algorithm
+	// requested in the Accept-Signature field.
+	AcceptSignatureAlg string
+
+	// Routes, if set, are verification policies for requests matching
+	// route patterns, using the http.ServeMux syntax. A route can override the
+	// required covered components, allowed algorithms and request body size,
+	// or allow unsigned requests. Requests which don't match a route are
+	// verified using the options above.
+	//
+	// If the patterns are invalid or conflict, all requests are rejected
+	// and the error is passed to OnValidationError once.
+	Routes []RoutePolicy
+
+	// OnFailure, if set, is called to write the response when
+	// a request fails signature verification.
+	//
+	// If nil, WriteProblem is used, which writes an 'application/problem+json' response.
+	OnFailure func(w http.ResponseWriter, r *http.Request, err error)
+
 	// OnDeriveSigningString is a hook which can be used to log
 	// the string to sign.
 	//
@@ -79,31 +167,109 @@ func Middleware(opts MiddlewareOpts) func(next http.Handler) http.Handler {
 		Scheme:                opts.Scheme,
 		Authority:             opts.Authority,
 		Tag:                   opts.Tag,
+		Chain:                 opts.Chain,
+		Policies:              opts.Policies,
+		PolicyMatch:           opts.PolicyMatch,
 		Validation:            DefaultValidationOpts(),
 		OnDeriveSigningString: opts.OnDeriveSigningString,
+		StreamBody:            opts.StreamBody,
+		SpillThreshold:        opts.SpillThreshold,
+		TempDir:               opts.TempDir,
+		MaxBodyBytes:          opts.MaxBodyBytes,
 	}
 
 	if opts.Validation != nil {
 		v.Validation = *opts.Validation
 	}
 
+	onFailure := opts.OnFailure
+	if onFailure == nil {
+		onFailure = WriteProblem
+	}
+
+	// configuration errors are known when the middleware is created,
+	// so they are reported once rather than for every request.
+	var configErrs []error
+
+	wantContentDigest, err := contentdigest.FormatPreferences(opts.WantContentDigest)
+	if err != nil {
+		configErrs = append(configErrs, fmt.Errorf("invalid WantContentDigest: %w", err))
+	}
+
+	wantReprDigest, err := contentdigest.FormatPreferences(opts.WantReprDigest)
+	if err != nil {
+		configErrs = append(configErrs, fmt.Errorf("invalid WantReprDigest: %w", err))
+	}
+
+	routes, err := newRouteTable(opts.Routes)
+	if err != nil {
+		configErrs = append(configErrs, err)
+	}
+
+	configErr := errors.Join(configErrs...)
+
+	var reportConfigErr sync.Once
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
+			// if the configuration is invalid, requests are rejected rather than
+			// being verified without the intended route policies.
+			if configErr != nil {
+				err := &verifier.Error{Reason: verifier.ReasonInternal, Err: configErr}
+				reportConfigErr.Do(func() {
+					if opts.OnValidationError != nil {
+						opts.OnValidationError(r.Context(), err)
+					}
+				})
+				onFailure(w, r, err)
+				return
+			}
+
+			rv := &v
+
+			if route := routes.match(r); route != nil {
+				if route.Unsigned {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				rv = route.apply(v)
+
+				if route.MaxBodyBytes > 0 && r.Body != nil {
+					r.Body = http.MaxBytesReader(w, r.Body, route.MaxBodyBytes)
+				}
+			}
+
+			if wantContentDigest != "" {
+				w.Header().Set("Want-Content-Digest", wantContentDigest)
+			}
+			if wantReprDigest != "" {
+				w.Header().Set("Want-Repr-Digest", wantReprDigest)
+			}
+
+			// verifying the request may replace the body with one backed by
+			// a temporary file, which is removed when the body is closed.
+			defer func() {
+				if r.Body != nil {
+					r.Body.Close()
+				}
+			}()
+
 			now := time.Now()
-			parsedReq, key, err := v.Parse(w, r, now)
+			parsedReq, key, err := rv.Parse(w, r, now)
 			if err != nil && opts.OnValidationError != nil {
 				opts.OnValidationError(r.Context(), err)
 			}
 
-			if errors.As(err, new(*http.MaxBytesError)) {
-				w.WriteHeader(http.StatusRequestEntityTooLarge)
-				_, _ = w.Write([]byte(http.StatusText(http.StatusRequestEntityTooLarge)))
-				return
-			}
-
 			if err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, _ = w.Write([]byte(http.StatusText(http.StatusUnauthorized)))
+				if opts.SendAcceptSignature {
+					acceptErr := sigset.IncludeAccept(w.Header(), acceptSignatures(*rv, opts.AcceptSignatureAlg))
+					if acceptErr != nil && opts.OnValidationError != nil {
+						opts.OnValidationError(r.Context(), acceptErr)
+					}
+				}
+
+				onFailure(w, r, err)
 				return
 			}
 
@@ -119,6 +285,31 @@ func Middleware(opts MiddlewareOpts) func(next http.Handler) http.Handler {
 	}
 }
 
+// acceptSignatures returns the signatures to request in the Accept-Signature
+// field, labelled 'sig1', 'sig2' etc in the order of the verifier's policies.
+func acceptSignatures(v verifier.Verifier, alg string) map[string]sigparams.AcceptSignature {
+	policies := v.Policies
+	if len(policies) == 0 {
+		policies = []verifier.Policy{{Tag: v.Tag}}
+	}
+
+	requests := make(map[string]sigparams.AcceptSignature, len(policies))
+
+	for i, p := range policies {
+		validation := v.Validation
+		if p.Validation != nil {
+			validation = *p.Validation
+		}
+
+		accept := validation.AcceptSignature(p.Tag)
+		accept.Alg = alg
+
+		requests["sig"+strconv.Itoa(i+1)] = accept
+	}
+
+	return requests
+}
+
 // DefaultValidationOpts provides sensible default validation options.
 func DefaultValidationOpts() sigparams.ValidateOpts {
 	return sigparams.ValidateOpts{
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/common-fate/httpsig/contentdigest"
 	"github.com/common-fate/httpsig/signer"
 )
 
@@ -40,6 +41,18 @@ type ClientOpts struct {
 	// as you can compare the base signing string between the client
 	// and server.
 	OnDeriveSigningString func(ctx context.Context, stringToSign string)
+
+	// RetryOnAcceptSignature, if true, retries requests which are rejected
+	// by the server with an Accept-Signature field, re-signing the request
+	// to cover the components requested by the server. Requests are only
+	// retried if the server requested components which were not covered.
+	RetryOnAcceptSignature bool
+
+	// NegotiateContentDigest, if true, uses the Want-Content-Digest and
+	// Want-Repr-Digest fields in responses to select the strongest digest
+	// algorithm supported by both the client and server for subsequent requests.
+	// Algorithms weaker than the digest algorithm of Alg are not used.
+	NegotiateContentDigest bool
 }
 
 // NewClient constructs a http.Client which signs
@@ -51,13 +64,20 @@ func NewClient(opts ClientOpts) *http.Client {
 		opts.CoveredComponents = DefaultCoveredComponents()
 	}
 
+	var negotiator *contentdigest.Negotiator
+	if opts.NegotiateContentDigest {
+		negotiator = &contentdigest.Negotiator{}
+	}
+
 	return &http.Client{
 		Transport: &signer.Transport{
-			KeyID:                 opts.KeyID,
-			Tag:                   opts.Tag,
-			Alg:                   opts.Alg,
-			CoveredComponents:     opts.CoveredComponents,
-			OnDeriveSigningString: opts.OnDeriveSigningString,
+			KeyID:                   opts.KeyID,
+			Tag:                     opts.Tag,
+			Alg:                     opts.Alg,
+			CoveredComponents:       opts.CoveredComponents,
+			OnDeriveSigningString:   opts.OnDeriveSigningString,
+			RetryOnAcceptSignature:  opts.RetryOnAcceptSignature,
+			ContentDigestNegotiator: negotiator,
 		},
 	}
 }
@@ -0,0 +1,130 @@
+package httpsig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// Problem is a problem details object, returned in the response
+// body when a request fails signature verification.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9457.html
+type Problem struct {
+	// Type is a URI reference identifying the problem type.
+	Type string `json:"type"`
+
+	// Title is a short summary of the problem type.
+	Title string `json:"title"`
+
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Reason is the machine-readable reason code for the failure.
+	Reason verifier.Reason `json:"reason"`
+}
+
+// ProblemTypeURI returns the problem type URI for a verification failure reason.
+func ProblemTypeURI(reason verifier.Reason) string {
+	return "urn:httpsig:problem:" + string(reason)
+}
+
+// problemTitles are the titles for each problem type.
+var problemTitles = map[verifier.Reason]string{
+	verifier.ReasonInternal:              "Signature could not be verified",
+	verifier.ReasonInvalidAuthority:      "Request authority is invalid",
+	verifier.ReasonMalformedSignature:    "Signature fields are malformed",
+	verifier.ReasonSignatureNotFound:     "Signature not found",
+	verifier.ReasonMultipleSignatures:    "Multiple matching signatures",
+	verifier.ReasonInvalidParams:         "Signature parameters are invalid",
+	verifier.ReasonSignatureExpired:      "Signature has expired",
+	verifier.ReasonSignatureNotYetValid:  "Signature is not yet valid",
+	verifier.ReasonNonceRequired:         "Signature nonce is required",
+	verifier.ReasonNonceReplayed:         "Signature nonce has already been used",
+	verifier.ReasonMissingComponent:      "Required component is not covered",
+	verifier.ReasonKeyNotFound:           "Signing key not found",
+	verifier.ReasonAlgorithmMismatch:     "Signing algorithm does not match the key",
+	verifier.ReasonAlgorithmNotAllowed:   "Signing algorithm is not allowed",
+	verifier.ReasonInvalidComponent:      "Covered component is invalid",
+	verifier.ReasonBodyTooLarge:          "Request body is too large",
+	verifier.ReasonContentDigestMismatch: "Content digest does not match the request body",
+	verifier.ReasonInvalidSignature:      "Signature is invalid",
+	verifier.ReasonSignatureNotCovered:   "Signature in chain is not covered",
+}
+
+// NewProblem returns the problem details for a verification error.
+//
+// Malformed Signature and Signature-Input fields result in a 400 Bad Request status,
+// request bodies exceeding the maximum size result in a 413 Request Entity Too Large status,
+// errors in the verifier's dependencies, such as the nonce storage or key directory,
+// result in a 500 Internal Server Error status, and all other failures result in a
+// 401 Unauthorized status.
+func NewProblem(err error) Problem {
+	reason := verifier.ReasonOf(err)
+
+	if reason == verifier.ReasonUnknown && errors.As(err, new(*http.MaxBytesError)) {
+		reason = verifier.ReasonBodyTooLarge
+	}
+
+	status := http.StatusUnauthorized
+
+	switch reason {
+	case verifier.ReasonMalformedSignature:
+		status = http.StatusBadRequest
+	case verifier.ReasonBodyTooLarge:
+		status = http.StatusRequestEntityTooLarge
+	case verifier.ReasonInternal, verifier.ReasonUnknown:
+		status = http.StatusInternalServerError
+	}
+
+	title, ok := problemTitles[reason]
+	if !ok {
+		title = http.StatusText(status)
+	}
+
+	p := Problem{
+		Type:   ProblemTypeURI(reason),
+		Title:  title,
+		Status: status,
+		Reason: reason,
+	}
+
+	// don't expose errors from the verifier's dependencies to clients,
+	// as they may contain details such as connection strings or file paths.
+	if status == http.StatusInternalServerError {
+		p.Detail = "The signature could not be verified due to a server error."
+	} else {
+		p.Detail = err.Error()
+	}
+
+	return p
+}
+
+// WriteProblem is the default failure responder for Middleware.
+//
+// It writes an 'application/problem+json' response describing the verification error.
+// Unauthorized responses include a WWW-Authenticate challenge containing the reason
+// code for the failure.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(err)
+
+	if p.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Signature error=%q", p.Reason))
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,12 @@
+/*
+Package redisnonce provides a verifier.NonceStorage backed by Redis,
+allowing replay protection to be shared between multiple servers.
+
+Nonces are stored using 'SET key value NX PX ttl', so each nonce is
+accepted at most once until it expires.
+
+Any Redis client can be used by implementing the Client interface.
+A minimal client using the Redis serialization protocol (RESP) is
+provided by NewRESPClient.
+*/
+package redisnonce
@@ -0,0 +1,166 @@
+package redisnonce
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is an in-process Redis server which supports
+// the subset of commands used by NonceStorage.
+type fakeServer struct {
+	ln       net.Listener
+	password string
+
+	mu   sync.Mutex
+	now  time.Time
+	keys map[string]time.Time
+}
+
+// newFakeServer starts a fakeServer, which is closed when the test finishes.
+//
+// If password is not empty, clients must authenticate using AUTH.
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("starting fake redis server: %v", err)
+	}
+
+	s := &fakeServer{
+		ln:       ln,
+		password: password,
+		now:      time.Now(),
+		keys:     map[string]time.Time{},
+	}
+
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+
+	return s
+}
+
+// SetTime sets the time used to expire keys.
+func (s *fakeServer) SetTime(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *fakeServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	authenticated := s.password == ""
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		var reply string
+
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			if args[len(args)-1] != s.password {
+				reply = "-WRONGPASS invalid password\r\n"
+				break
+			}
+			authenticated = true
+			reply = "+OK\r\n"
+
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+
+		case cmd == "PING":
+			reply = "+PONG\r\n"
+
+		case cmd == "SET":
+			reply = s.set(args[1:])
+
+		default:
+			reply = fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+		}
+
+		_, err = conn.Write([]byte(reply))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// set handles 'SET key value NX PX ms'.
+func (s *fakeServer) set(args []string) string {
+	if len(args) != 5 || strings.ToUpper(args[2]) != "NX" || strings.ToUpper(args[3]) != "PX" {
+		return "-ERR syntax error\r\n"
+	}
+
+	ms, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil || ms <= 0 {
+		return "-ERR invalid expire time in 'set' command\r\n"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now
+
+	if expiresAt, ok := s.keys[args[0]]; ok && now.Before(expiresAt) {
+		return "$-1\r\n"
+	}
+
+	s.keys[args[0]] = now.Add(time.Duration(ms) * time.Millisecond)
+	return "+OK\r\n"
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("expected array, got %q", line)
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid array length %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		reply, err := readReply(r)
+		if err != nil {
+			return nil, err
+		}
+		arg, ok := reply.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected bulk string, got %T", reply)
+		}
+		args[i] = arg
+	}
+
+	return args, nil
+}
@@ -0,0 +1,126 @@
+package redisnonce
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// getCurrentTime allows the current time to be overridden for testing.
+var getCurrentTime = time.Now
+
+const (
+	// DefaultTTL is the default duration that nonces are stored for.
+	DefaultTTL = 2 * time.Minute
+
+	// DefaultPrefix is the default prefix for nonce keys.
+	DefaultPrefix = "httpsig:nonce:"
+)
+
+// Client sends commands to a Redis server.
+//
+// Do sends a command made up of the provided arguments, and returns the reply.
+// A nil reply must be returned as a nil value with a nil error.
+//
+// For example, a github.com/redis/go-redis client can be adapted with:
+//
+//	func (c adapter) Do(ctx context.Context, args ...string) (any, error) {
+//		cmdArgs := make([]any, len(args))
+//		for i, a := range args {
+//			cmdArgs[i] = a
+//		}
+//		res, err := c.rdb.Do(ctx, cmdArgs...).Result()
+//		if err == redis.Nil {
+//			return nil, nil
+//		}
+//		return res, err
+//	}
+type Client interface {
+	Do(ctx context.Context, args ...string) (any, error)
+}
+
+// NonceStorage implements the verifier.NonceStorage interface
+// by storing nonces in Redis.
+type NonceStorage struct {
+	// Client is the Redis client.
+	Client Client
+
+	// Prefix is prepended to the keys used to store nonces.
+	//
+	// If empty, DefaultPrefix is used.
+	Prefix string
+
+	// TTL is the minimum duration that a nonce is stored for,
+	// measured from the time the signature was created.
+	//
+	// TTL should be at least ValidateOpts.BeforeDuration + ValidateOpts.AfterDuration.
+	//
+	// If zero, DefaultTTL is used.
+	TTL time.Duration
+}
+
+var _ verifier.NonceStorage = &NonceStorage{}
+var _ verifier.NonceRecordStorage = &NonceStorage{}
+
+// Seen returns true if the nonce has been previously seen.
+func (s *NonceStorage) Seen(ctx context.Context, nonce string) (bool, error) {
+	return s.SeenWithContext(ctx, verifier.NonceRecord{Nonce: nonce})
+}
+
+// SeenWithContext returns true if the nonce has been previously seen.
+//
+// Nonces are scoped to the key ID of the signature.
+func (s *NonceStorage) SeenWithContext(ctx context.Context, record verifier.NonceRecord) (bool, error) {
+	ttl := s.ttl(record)
+
+	reply, err := s.Client.Do(ctx, "SET", s.key(record), "1", "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, fmt.Errorf("storing nonce in redis: %w", err)
+	}
+
+	// SET with NX returns a nil reply if the key already exists.
+	if reply == nil {
+		return true, nil
+	}
+
+	if reply != "OK" {
+		return false, fmt.Errorf("unexpected reply from redis SET command: %v", reply)
+	}
+
+	return false, nil
+}
+
+// key returns the Redis key for the nonce.
+func (s *NonceStorage) key(record verifier.NonceRecord) string {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	// escape the key ID so that it can't contain the separator.
+	return prefix + url.QueryEscape(record.KeyID) + ":" + record.Nonce
+}
+
+// ttl returns the duration to store the nonce for.
+//
+// If the signature was created in the future, the nonce is stored
+// until TTL has passed from the created time.
+func (s *NonceStorage) ttl(record verifier.NonceRecord) time.Duration {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	if !record.Created.IsZero() {
+		if remaining := record.Created.Add(ttl).Sub(getCurrentTime()); remaining > ttl {
+			ttl = remaining
+		}
+	}
+
+	// Redis expiry is measured in milliseconds.
+	return ttl.Round(time.Millisecond)
+}
@@ -0,0 +1,146 @@
+package redisnonce
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+func TestNonceStorage(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	getCurrentTime = func() time.Time { return now }
+	defer func() { getCurrentTime = time.Now }()
+
+	server := newFakeServer(t, "")
+	server.SetTime(now)
+	client := NewRESPClient(server.Addr())
+	defer client.Close()
+
+	s := &NonceStorage{Client: client, TTL: time.Minute}
+
+	seen := func(s *NonceStorage, record verifier.NonceRecord) bool {
+		t.Helper()
+		got, err := s.SeenWithContext(ctx, record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	first := verifier.NonceRecord{Nonce: "first", KeyID: "alice", Created: now}
+
+	if seen(s, first) {
+		t.Fatal("expected first nonce not to be seen")
+	}
+	if !seen(s, first) {
+		t.Fatal("expected first nonce to be seen")
+	}
+
+	// nonces are scoped to the key ID.
+	if seen(s, verifier.NonceRecord{Nonce: "first", KeyID: "bob", Created: now}) {
+		t.Fatal("expected nonce not to be seen for a different key ID")
+	}
+
+	// nonces are shared between storage instances using the same server.
+	other := &NonceStorage{Client: NewRESPClient(server.Addr()), TTL: time.Minute}
+	if !seen(other, first) {
+		t.Fatal("expected first nonce to be seen by another instance")
+	}
+
+	// the nonce is remembered for the TTL.
+	now = now.Add(59 * time.Second)
+	server.SetTime(now)
+	if !seen(s, first) {
+		t.Fatal("expected first nonce to be seen before the TTL")
+	}
+
+	now = now.Add(2 * time.Second)
+	server.SetTime(now)
+	if seen(s, first) {
+		t.Fatal("expected first nonce to be forgotten after it expired")
+	}
+
+	// a signature created in the future is remembered until
+	// the TTL has passed from the created time.
+	future := verifier.NonceRecord{Nonce: "future", Created: now.Add(30 * time.Second)}
+	if seen(s, future) {
+		t.Fatal("expected future nonce not to be seen")
+	}
+
+	now = now.Add(80 * time.Second)
+	server.SetTime(now)
+	if !seen(s, future) {
+		t.Fatal("expected future nonce to be seen before the TTL from its created time")
+	}
+
+	// Seen uses an unscoped key.
+	got, err := s.Seen(ctx, "unscoped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got {
+		t.Fatal("expected unscoped nonce not to be seen")
+	}
+}
+
+func TestNonceStorage_Auth(t *testing.T) {
+	server := newFakeServer(t, "secret")
+
+	s := &NonceStorage{Client: NewRESPClient(server.Addr())}
+
+	_, err := s.Seen(context.Background(), "nonce")
+	var replyErr Error
+	if !errors.As(err, &replyErr) {
+		t.Fatalf("expected a redis error reply when not authenticated, got %v", err)
+	}
+
+	client := NewRESPClient(server.Addr())
+	client.Password = "secret"
+	s = &NonceStorage{Client: client}
+
+	_, err = s.Seen(context.Background(), "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+type errorClient struct {
+	reply any
+	err   error
+}
+
+func (c errorClient) Do(ctx context.Context, args ...string) (any, error) {
+	return c.reply, c.err
+}
+
+func TestNonceStorage_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		client Client
+	}{
+		{
+			name:   "client_error",
+			client: errorClient{err: errors.New("connection refused")},
+		},
+		{
+			name:   "unexpected_reply",
+			client: errorClient{reply: int64(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &NonceStorage{Client: tt.client}
+
+			// an error must not be treated as an unseen nonce.
+			_, err := s.Seen(context.Background(), "nonce")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package redisnonce
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DefaultPoolSize is the default maximum number of idle connections.
+const DefaultPoolSize = 10
+
+// Error is an error reply from the Redis server.
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// RESPClient is a minimal Redis client which uses the
+// Redis serialization protocol (RESP) over TCP.
+//
+// Replies are returned as a string for simple strings and bulk strings,
+// int64 for integers, []any for arrays, and nil for nil replies.
+// Error replies are returned as an Error.
+//
+// See: https://redis.io/docs/latest/develop/reference/protocol-spec/
+type RESPClient struct {
+	// Addr is the address of the Redis server, such as 'localhost:6379'.
+	Addr string
+
+	// Username and Password, if set, are used to authenticate
+	// new connections using the AUTH command.
+	Username string
+	Password string
+
+	// DialContext, if set, is used to dial new connections.
+	// It can be used to connect to Redis over TLS.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Timeout, if non-zero, is the maximum duration of each command.
+	Timeout time.Duration
+
+	idle chan *respConn
+}
+
+var _ Client = &RESPClient{}
+
+// NewRESPClient creates a RESPClient for the Redis server at addr.
+func NewRESPClient(addr string) *RESPClient {
+	return &RESPClient{
+		Addr: addr,
+		idle: make(chan *respConn, DefaultPoolSize),
+	}
+}
+
+type respConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Do sends a command to the Redis server and returns the reply.
+func (c *RESPClient) Do(ctx context.Context, args ...string) (any, error) {
+	if len(args) == 0 {
+		return nil, errors.New("redis: command was empty")
+	}
+
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(ctx, c.Timeout, args)
+
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		// the connection may be in an inconsistent state.
+		conn.conn.Close()
+		return nil, err
+	}
+
+	c.put(conn)
+
+	return reply, err
+}
+
+// Close closes any idle connections.
+func (c *RESPClient) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// get returns an idle connection, or dials a new connection.
+func (c *RESPClient) get(ctx context.Context) (*respConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dial := c.DialContext
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+
+	netConn, err := dial(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: connecting to %s: %w", c.Addr, err)
+	}
+
+	conn := &respConn{conn: netConn, r: bufio.NewReader(netConn)}
+
+	if c.Password != "" {
+		args := []string{"AUTH", c.Password}
+		if c.Username != "" {
+			args = []string{"AUTH", c.Username, c.Password}
+		}
+
+		_, err = conn.do(ctx, c.Timeout, args)
+		if err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("redis: authenticating: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// put returns a connection to the pool, closing it if the pool is full.
+func (c *RESPClient) put(conn *respConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+func (c *respConn) do(ctx context.Context, timeout time.Duration, args []string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if timeout > 0 {
+		if d := time.Now().Add(timeout); !ok || d.Before(deadline) {
+			deadline, ok = d, true
+		}
+	}
+	if ok {
+		_ = c.conn.SetDeadline(deadline)
+	} else {
+		_ = c.conn.SetDeadline(time.Time{})
+	}
+
+	_, err := c.conn.Write(encodeCommand(args))
+	if err != nil {
+		return nil, fmt.Errorf("redis: writing command: %w", err)
+	}
+
+	return readReply(c.r)
+}
+
+// encodeCommand encodes a command as an array of bulk strings.
+func encodeCommand(args []string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readReply reads a RESP reply.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	payload := line[1:]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+
+	case '-':
+		return nil, Error(payload)
+
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid integer reply: %w", err)
+		}
+		return n, nil
+
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk string length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, fmt.Errorf("redis: reading bulk string: %w", err)
+		}
+		return string(buf[:n]), nil
+
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i], err = readReply(r)
+			var replyErr Error
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+}
+
+// readLine reads a CRLF-terminated line, without the CRLF.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("redis: reading reply: %w", err)
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("redis: malformed reply")
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,86 @@
+package httpsig
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/common-fate/httpsig/signer"
+)
+
+type ResponseSigningOpts struct {
+	// KeyID is the identifier for the key to use for signing responses.
+	KeyID string
+
+	// Tag is an application-specific tag for the signature as a String value.
+	// This value is used by applications to help identify signatures relevant for specific applications or protocols.
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.3-4.12
+	Tag string
+
+	// Alg is the signing algorithm to use.
+	Alg signer.Algorithm
+
+	// CoveredComponents overrides the default covered components used for signing.
+	//
+	// If not provided, the following covered components are used:
+	// ["@status", "content-length", "content-digest"]
+	//
+	// Components of the request can be covered using the 'req' parameter,
+	// such as '@method;req', to bind the response to the request.
+	CoveredComponents []string
+
+	// Scheme is the URL scheme used when deriving request components
+	// with the 'req' parameter.
+	//
+	// Should be 'https' in production.
+	Scheme string
+
+	// Authority is the HTTP authority used when deriving request components
+	// with the 'req' parameter.
+	Authority string
+
+	// OnSigningError, if set, is called when a response could not be signed
+	// with the request context.
+	OnSigningError func(ctx context.Context, err error)
+
+	// OnDeriveSigningString is a hook which can be used to log
+	// the string to sign.
+	//
+	// This can be useful for debugging signature errors,
+	// as you can compare the base signing string between the client
+	// and server.
+	OnDeriveSigningString func(ctx context.Context, stringToSign string)
+}
+
+// ResponseSigningMiddleware is an HTTP server middleware which signs
+// outgoing responses, allowing clients to verify that a response was
+// sent by the server.
+//
+// Responses are buffered in memory before being signed, unless the
+// handler flushes a response whose body is not covered by the signature.
+// See signer.ResponseSigner.Handler.
+//
+// For more control, you can use signer.ResponseSigner directly.
+func ResponseSigningMiddleware(opts ResponseSigningOpts) func(next http.Handler) http.Handler {
+	if opts.CoveredComponents == nil {
+		opts.CoveredComponents = DefaultResponseCoveredComponents()
+	}
+
+	s := &signer.ResponseSigner{
+		KeyID:                 opts.KeyID,
+		Tag:                   opts.Tag,
+		Alg:                   opts.Alg,
+		CoveredComponents:     opts.CoveredComponents,
+		Scheme:                opts.Scheme,
+		Authority:             opts.Authority,
+		OnSigningError:        opts.OnSigningError,
+		OnDeriveSigningString: opts.OnDeriveSigningString,
+	}
+
+	return s.Handler
+}
+
+// DefaultResponseCoveredComponents returns a sensible default for the covered components
+// field when signing responses.
+func DefaultResponseCoveredComponents() []string {
+	return []string{"@status", "content-length", "content-digest"}
+}
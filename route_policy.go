@@ -0,0 +1,157 @@
+package httpsig
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// RoutePolicy is the verification policy for requests matching a route.
+type RoutePolicy struct {
+	// Pattern is the route pattern, using the http.ServeMux syntax,
+	// such as 'POST /payments' or 'GET /health'.
+	//
+	// If a request matches multiple patterns, the most
+	// specific pattern is used, as with http.ServeMux. Requests which
+	// http.ServeMux would redirect, such as paths containing '..' or '//',
+	// don't match any routes and are verified using the default options.
+	Pattern string
+
+	// Unsigned, if true, requests matching the route are not verified,
+	// and are passed to the next handler unchanged.
+	Unsigned bool
+
+	// RequiredCoveredComponents, if set, are the components which must
+	// be covered by the signature, replacing the RequiredCoveredComponents
+	// validation option, such as ["@method", "@target-uri", "content-digest", "idempotency-key"].
+	RequiredCoveredComponents []string
+
+	// AllowedAlgorithms, if set, are the signing
+	// algorithms which are accepted for the route.
+	AllowedAlgorithms []string
+
+	// MaxBodyBytes, if non-zero, is the maximum size of the request body.
+	// Requests with a larger body are rejected with a 413 Request Entity Too Large status.
+	MaxBodyBytes int64
+}
+
+// apply returns a copy of the verifier with the route policy applied.
+func (p *RoutePolicy) apply(v verifier.Verifier) *verifier.Verifier {
+	if p.RequiredCoveredComponents != nil {
+		required := make(map[string]bool, len(p.RequiredCoveredComponents))
+		for _, cc := range p.RequiredCoveredComponents {
+			required[cc] = true
+		}
+
+		v.Validation.RequiredCoveredComponents = required
+
+		// policies with their own validation options are also overridden.
+		policies := make([]verifier.Policy, len(v.Policies))
+		for i, policy := range v.Policies {
+			if policy.Validation != nil {
+				validation := *policy.Validation
+				validation.RequiredCoveredComponents = required
+				policy.Validation = &validation
+			}
+			policies[i] = policy
+		}
+		v.Policies = policies
+	}
+
+	if p.AllowedAlgorithms != nil {
+		v.AllowedAlgorithms = p.AllowedAlgorithms
+	}
+
+	if p.MaxBodyBytes > 0 {
+		v.MaxBodyBytes = p.MaxBodyBytes
+	}
+
+	return &v
+}
+
+// routeTable matches requests to route policies using the
+// pattern matching of http.ServeMux.
+type routeTable struct {
+	mux    *http.ServeMux
+	routes map[string]*RoutePolicy
+}
+
+func newRouteTable(routes []RoutePolicy) (*routeTable, error) {
+	t := &routeTable{
+		mux:    http.NewServeMux(),
+		routes: make(map[string]*RoutePolicy, len(routes)),
+	}
+
+	for _, route := range routes {
+		err := t.handle(route.Pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		t.routes[route.Pattern] = &route
+	}
+
+	return t, nil
+}
+
+// handle registers a pattern with the mux, returning an error
+// rather than panicking if the pattern is invalid or conflicts
+// with another pattern.
+func (t *routeTable) handle(pattern string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid route pattern %q: %v", pattern, r)
+		}
+	}()
+
+	t.mux.Handle(pattern, routeHandler{})
+
+	return nil
+}
+
+// match returns the route policy for the request,
+// or nil if the request doesn't match any routes.
+//
+// Requests which the mux would redirect, such as paths containing '..'
+// or '//', or which are missing a trailing slash, don't match any routes.
+// The pattern returned by the mux for these requests is the pattern of the
+// redirect target, which the request itself may not be routed to.
+func (t *routeTable) match(r *http.Request) *RoutePolicy {
+	if r.URL.Path != cleanPath(r.URL.Path) {
+		return nil
+	}
+
+	h, pattern := t.mux.Handler(r)
+	if _, ok := h.(routeHandler); !ok {
+		return nil
+	}
+
+	return t.routes[pattern]
+}
+
+// cleanPath returns the canonical path for p, preserving the trailing
+// slash, as http.ServeMux does before matching a request.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+// routeHandler is registered with the mux for each route pattern.
+// The mux returns a different handler, such as a redirect, for
+// requests which don't match a pattern directly.
+type routeHandler struct{}
+
+func (routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
@@ -19,6 +19,13 @@ type Base struct {
 	// and whitespace trimming / obsolete line folding is not
 	// performed on these.
 	Header http.Header
+
+	// Trailer is an HTTP header with key/value pairs
+	// from the message trailers that have been added to the base,
+	// for components with the 'tr' parameter.
+	//
+	// Trailer is nil if no trailer fields are covered.
+	Trailer http.Header
 }
 
 func New() *Base {
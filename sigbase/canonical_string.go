@@ -5,6 +5,7 @@ import (
 
 	"github.com/common-fate/httpsig/ascii"
 	"github.com/common-fate/httpsig/sigparams"
+	"github.com/dunglas/httpsfv"
 )
 
 // CanonicalString returns the canonical signing string based on the signature
@@ -29,18 +30,23 @@ func (b Base) CanonicalString(params sigparams.Params) (string, error) {
 
 		// Append the component identifier for the covered component serialized according to the component-identifier ABNF rule. Note that this serialization places the component name
 		// in double quotes and appends any parameters outside of the quotes.
-		// NOTE(chrnorm): we currently support a subset of this and only support component names, not parameters.
-		_, err := output.WriteString(`"`)
+		c, err := sigparams.ParseComponent(cc)
 		if err != nil {
 			return "", err
 		}
-		_, err = output.WriteString(cc)
+
+		identifier, err := httpsfv.Marshal(c.Item())
+		if err != nil {
+			return "", fmt.Errorf("serializing component identifier %q: %w", cc, err)
+		}
+
+		_, err = output.WriteString(identifier)
 		if err != nil {
 			return "", err
 		}
 		// Append a single colon (:).
 		// Append a single space (" ").
-		_, err = output.WriteString(`": `)
+		_, err = output.WriteString(`: `)
 		if err != nil {
 			return "", err
 		}
@@ -53,7 +59,7 @@ func (b Base) CanonicalString(params sigparams.Params) (string, error) {
 		// Append the covered component's canonicalized component value.
 		_, err = output.WriteString(val)
 		if err != nil {
-			return "", err
+			return "", fmt.Errorf("writing value for covered component %q: use the 'bs' parameter to cover HTTP fields containing non-ASCII characters: %w", cc, err)
 		}
 
 		// Append a single newline (\n).
@@ -2,9 +2,11 @@ package sigbase
 
 import (
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/common-fate/httpsig/contentdigest"
 	"github.com/common-fate/httpsig/sigparams"
 )
 
@@ -73,6 +75,40 @@ func TestBase_CanonicalString(t *testing.T) {
 			},
 			want: `"content-length": 5
 "@signature-params": ("content-length");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";created=1704254706`,
+		},
+		{
+			name: "with_component_params",
+			base: Base{
+				Values: map[string]string{
+					"@status":         "200",
+					"@method;req":     "POST",
+					"@target-uri;req": "https://example.com/",
+				},
+			},
+			params: sigparams.Params{
+				CoveredComponents: []string{"@status", "@method;req", "@target-uri;req"},
+				Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			},
+			want: `"@status": 200
+"@method";req: POST
+"@target-uri";req: https://example.com/
+"@signature-params": ("@status" "@method";req "@target-uri";req);created=1704254706`,
+		},
+		{
+			name: "with_structured_field_params",
+			base: Base{
+				Values: map[string]string{
+					"cache-control;sf": "max-age=60, must-revalidate",
+					`priority;key="u"`: "1",
+				},
+			},
+			params: sigparams.Params{
+				CoveredComponents: []string{"cache-control;sf", `priority;key="u"`},
+				Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			},
+			want: `"cache-control";sf: max-age=60, must-revalidate
+"priority";key="u": 1
+"@signature-params": ("cache-control";sf "priority";key="u");created=1704254706`,
 		},
 		{
 			name: "with_content_digest",
@@ -107,3 +143,52 @@ func TestBase_CanonicalString(t *testing.T) {
 		})
 	}
 }
+
+// TestBase_CanonicalString_NonASCII tests that fields containing non-ASCII
+// characters can only be covered using the 'bs' parameter.
+func TestBase_CanonicalString_NonASCII(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Filename", "résumé.pdf")
+
+	params := sigparams.Params{
+		CoveredComponents: []string{"x-filename"},
+		Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+	}
+
+	base, err := Derive(params, nil, req, contentdigest.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = base.CanonicalString(params)
+	if err == nil {
+		t.Fatal("expected an error deriving the canonical string for a non-ASCII field")
+	}
+
+	params.CoveredComponents = []string{"x-filename;bs"}
+
+	base, err = Derive(params, nil, req, contentdigest.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := base.CanonicalString(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `"x-filename";bs: :csOpc3Vtw6kucGRm:
+"@signature-params": ("x-filename";bs);created=1704254706`
+
+	if got != want {
+		t.Fatalf("want = %s, got = %s", want, got)
+	}
+
+	// the field is still included in the covered headers.
+	if base.Header.Get("X-Filename") != "résumé.pdf" {
+		t.Fatalf("expected X-Filename to be a covered header, got %v", base.Header)
+	}
+}
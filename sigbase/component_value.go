@@ -2,12 +2,16 @@ package sigbase
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"unicode"
 
 	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigparams"
 	"github.com/dunglas/httpsfv"
 )
 
@@ -40,16 +44,40 @@ type Hasher interface {
 // value as described in Section 2.1, including processing of any known valid parameters.
 // If the field cannot be found in the message or the value cannot be obtained in the context,
 // produce an error.
-func getComponentValue(identifier string, w http.ResponseWriter, r *http.Request, digester contentdigest.Digester) (string, error) {
-	if identifier == "" {
-		return "", errors.New("identifier was empty")
+//
+// If declaredDigest is true, the content-digest and repr-digest components are
+// read from the request headers rather than by hashing the request body.
+func getComponentValue(identifier string, w http.ResponseWriter, r *http.Request, digester contentdigest.Digester, declaredDigest bool) (string, error) {
+	c, err := parseComponent(identifier)
+	if err != nil {
+		return "", err
+	}
+
+	if c.HasParam("req") {
+		return "", errors.New("the 'req' parameter may only be used when the target message is a response")
 	}
 
-	if !isLower(identifier) {
-		return "", errors.New("identifier must be lowercase")
+	return getRequestComponentValue(c, w, r, digester, declaredDigest)
+}
+
+// getRequestComponentValue determines the component value for a component on a HTTP request.
+//
+// If declaredDigest is true, the content-digest and repr-digest fields are read from the
+// request headers rather than by hashing the request body. This is the case for the related
+// request of a response signature, as the request body has already been consumed.
+func getRequestComponentValue(c sigparams.Component, w http.ResponseWriter, r *http.Request, digester contentdigest.Digester, declaredDigest bool) (string, error) {
+	if r == nil {
+		return "", errors.New("the related request for the response was not provided")
 	}
 
-	switch identifier {
+	// The 'tr' parameter indicates that the field value is taken from the
+	// trailers of the message, which are available after the body has been read.
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.4
+	if c.HasParam("tr") {
+		return getFieldValue(c, r.Trailer.Values(c.Name))
+	}
+
+	switch c.Name {
 	case "@signature-params":
 		return "", errors.New("@signature-params may not be included in the covered components")
 
@@ -89,14 +117,74 @@ func getComponentValue(identifier string, w http.ResponseWriter, r *http.Request
 		}
 		return val, nil
 
+	case "@request-target":
+		// The @request-target component value is the full request target
+		// of the HTTP request message, as it would appear in the HTTP/1.1 request line.
+		// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.2.5
+		return r.URL.RequestURI(), nil
+
+	case "@path":
+		// The @path component value is the target path of the request,
+		// which must be an absolute path. An empty path string is
+		// normalized as a single slash (/) character.
+		// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.2.6
+		val := r.URL.EscapedPath()
+		if val == "" {
+			val = "/"
+		}
+		return val, nil
+
+	case "@query":
+		// The @query component value is the entire normalized query string,
+		// including the leading question mark (?) character. If the query
+		// string is absent, the component value is a single question mark.
+		// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.2.7
+		return "?" + r.URL.RawQuery, nil
+
+	case "@query-param":
+		return getQueryParamValue(c, r)
+
 	case "content-length":
-		return httpsfv.Marshal(httpsfv.NewItem(r.ContentLength))
+		// a request sent using chunked encoding has an unknown length,
+		// so the length can't be agreed between the signer and verifier.
+		if r.ContentLength < 0 {
+			return "", errors.New("request content length is unknown")
+		}
+		length, err := httpsfv.Marshal(httpsfv.NewItem(r.ContentLength))
+		if err != nil {
+			return "", err
+		}
+		return getFieldValue(c, []string{length})
 
 	case "content-digest":
-		return digester.HashRequest(w, r)
+		if declaredDigest {
+			return getFieldValue(c, r.Header.Values(c.Name))
+		}
+		digest, err := digester.HashRequest(w, r)
+		if err != nil {
+			return "", err
+		}
+		return getFieldValue(c, []string{digest})
+
+	case "repr-digest":
+		// the Repr-Digest field is used if it has been set, as the
+		// representation may not be the same as the request content.
+		if declared := r.Header.Values(c.Name); declaredDigest || len(declared) > 0 {
+			return getFieldValue(c, declared)
+		}
+		// without a Content-Range, the request content is the full representation.
+		// See: https://www.rfc-editor.org/rfc/rfc9530.html#section-3
+		if r.Header.Get("Content-Range") != "" {
+			return "", errors.New("the Repr-Digest field must be set for a partial representation")
+		}
+		digest, err := digester.HashRequest(w, r)
+		if err != nil {
+			return "", err
+		}
+		return getFieldValue(c, []string{digest})
 	}
 
-	if identifier[0] == '@' {
+	if c.Name[0] == '@' {
 		// the derived component name is unknown
 		return "", errors.New("unknown component name")
 	}
@@ -108,8 +196,133 @@ func getComponentValue(identifier string, w http.ResponseWriter, r *http.Request
 
 	// Create an ordered list of the field values of each instance of the field in the
 	// message, in the order they occur (or will occur) in the message.
-	values := r.Header.Values(identifier)
+	return getFieldValue(c, r.Header.Values(c.Name))
+}
+
+// getResponseComponentValue determines the component value for the component identifier
+// on a HTTP response, following the same process as getComponentValue.
+//
+// The only derived component which applies to a response is @status.
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.2.9
+//
+// If the component identifier contains the req parameter, the component value
+// is derived from res.Request, the request which the response was sent in reply to.
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.4
+//
+// If declaredDigest is true, the content-digest component is read from the
+// response headers rather than by hashing the response body.
+func getResponseComponentValue(identifier string, res *http.Response, digester contentdigest.Digester, declaredDigest bool) (string, error) {
+	c, err := parseComponent(identifier)
+	if err != nil {
+		return "", err
+	}
+
+	if c.HasParam("req") {
+		c.Params.Del("req")
+		return getRequestComponentValue(c, nil, res.Request, digester, true)
+	}
+
+	if c.HasParam("tr") {
+		return getFieldValue(c, res.Trailer.Values(c.Name))
+	}
+
+	switch c.Name {
+	case "@signature-params":
+		return "", errors.New("@signature-params may not be included in the covered components")
+
+	case "@status":
+		// The @status component value is the three-digit numeric HTTP status code
+		// of the response.
+		if res.StatusCode < 100 || res.StatusCode > 999 {
+			return "", fmt.Errorf("invalid response status code %d", res.StatusCode)
+		}
+		return strconv.Itoa(res.StatusCode), nil
+
+	case "content-length":
+		if res.ContentLength < 0 {
+			return "", errors.New("response content length is unknown")
+		}
+		length, err := httpsfv.Marshal(httpsfv.NewItem(res.ContentLength))
+		if err != nil {
+			return "", err
+		}
+		return getFieldValue(c, []string{length})
+
+	case "content-digest":
+		if declaredDigest {
+			return getFieldValue(c, res.Header.Values(c.Name))
+		}
+		digest, err := digester.HashResponse(res)
+		if err != nil {
+			return "", err
+		}
+		return getFieldValue(c, []string{digest})
+	}
+
+	if c.Name[0] == '@' {
+		// the derived component name is unknown, or refers
+		// to a request, which is not the target message.
+		return "", errors.New("unknown component name for a response")
+	}
+
+	return getFieldValue(c, res.Header.Values(c.Name))
+}
 
+// knownParams are the component parameters understood by this implementation.
+var knownParams = map[string]bool{
+	"req":  true,
+	"name": true,
+	"sf":   true,
+	"key":  true,
+	"bs":   true,
+	"tr":   true,
+}
+
+// parseComponent parses a component identifier, returning an error if
+// the component has a parameter that is not understood.
+func parseComponent(identifier string) (sigparams.Component, error) {
+	if identifier == "" {
+		return sigparams.Component{}, errors.New("identifier was empty")
+	}
+
+	c, err := sigparams.ParseComponent(identifier)
+	if err != nil {
+		return sigparams.Component{}, err
+	}
+
+	if !isLower(c.Name) {
+		return sigparams.Component{}, errors.New("identifier must be lowercase")
+	}
+
+	for _, param := range c.Params.Names() {
+		if !knownParams[param] {
+			return sigparams.Component{}, fmt.Errorf("unknown component parameter %q", param)
+		}
+	}
+
+	if c.HasParam("name") && c.Name != "@query-param" {
+		return sigparams.Component{}, errors.New("the 'name' parameter may only be used with the @query-param component")
+	}
+
+	if c.Name[0] == '@' && (c.HasParam("sf") || c.HasParam("key") || c.HasParam("bs") || c.HasParam("tr")) {
+		return sigparams.Component{}, errors.New("the 'sf', 'key', 'bs' and 'tr' parameters may only be used with HTTP fields")
+	}
+
+	// The 'bs' parameter is incompatible with the 'sf' and 'key' parameters,
+	// as a byte sequence is not a structured field.
+	if c.HasParam("bs") && (c.HasParam("sf") || c.HasParam("key")) {
+		return sigparams.Component{}, errors.New("the 'bs' parameter cannot be combined with the 'sf' or 'key' parameters")
+	}
+
+	return c, nil
+}
+
+// canonicalizeField canonicalizes the field values for a HTTP field,
+// as described in https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1
+//
+// values are the field values of each instance of the field in the message,
+// in the order they occur in the message.
+func canonicalizeField(values []string) (string, error) {
 	if len(values) == 0 {
 		return "", errors.New("HTTP header was empty")
 	}
@@ -145,6 +358,69 @@ func getComponentValue(identifier string, w http.ResponseWriter, r *http.Request
 	return itemStr, nil
 }
 
+// getQueryParamValue returns the value of a single query parameter
+// specified by the 'name' parameter on the @query-param component.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.2.8
+func getQueryParamValue(c sigparams.Component, r *http.Request) (string, error) {
+	nameParam, ok := c.Params.Get("name")
+	if !ok {
+		return "", errors.New("the @query-param component requires the 'name' parameter")
+	}
+	name, ok := nameParam.(string)
+	if !ok {
+		return "", errors.New("the 'name' parameter must be a string")
+	}
+
+	// Parse the query string using the application/x-www-form-urlencoded parsing algorithm,
+	// and encode the resulting names and values using percent-encoding.
+	var values []string
+
+	for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			return "", fmt.Errorf("decoding query parameter name: %w", err)
+		}
+
+		if encodeQueryParam(key) != name {
+			continue
+		}
+
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			return "", fmt.Errorf("decoding query parameter %q: %w", name, err)
+		}
+
+		values = append(values, encodeQueryParam(value))
+	}
+
+	if len(values) == 0 {
+		return "", fmt.Errorf("query parameter %q was not present", name)
+	}
+
+	// If a parameter name occurs multiple times in a request,
+	// the named parameter MUST NOT be included.
+	if len(values) > 1 {
+		return "", fmt.Errorf("query parameter %q occurred multiple times: use the @query component to cover the entire query string instead", name)
+	}
+
+	return values[0], nil
+}
+
+// encodeQueryParam percent-encodes a decoded query parameter name or value.
+// Spaces are encoded as '%20' rather than '+'.
+func encodeQueryParam(s string) string {
+	// url.QueryEscape encodes a literal '+' as '%2B',
+	// so any remaining '+' characters represent spaces.
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
 func isLower(s string) bool {
 	for _, r := range s {
 		if !unicode.IsLower(r) && unicode.IsLetter(r) {
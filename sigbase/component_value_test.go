@@ -1,7 +1,9 @@
 package sigbase
 
 import (
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/common-fate/httpsig/contentdigest"
@@ -63,6 +65,160 @@ func Test_getComponentValue(t *testing.T) {
 			},
 			want: "example.com",
 		},
+		{
+			name: "request_target",
+			args: args{
+				identifier: `@request-target`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value", nil)
+					return req
+				},
+			},
+			want: "/path?param=value",
+		},
+		{
+			name: "path",
+			args: args{
+				identifier: `@path`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value", nil)
+					return req
+				},
+			},
+			want: "/path",
+		},
+		{
+			name: "empty_path",
+			args: args{
+				identifier: `@path`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					return req
+				},
+			},
+			want: "/",
+		},
+		{
+			name: "query",
+			args: args{
+				identifier: `@query`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value&foo=bar&baz=bat%2Dman", nil)
+					return req
+				},
+			},
+			want: "?param=value&foo=bar&baz=bat%2Dman",
+		},
+		{
+			name: "empty_query",
+			args: args{
+				identifier: `@query`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path", nil)
+					return req
+				},
+			},
+			want: "?",
+		},
+		{
+			name: "query_param",
+			args: args{
+				identifier: `@query-param;name="baz"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value&foo=bar&baz=bat%2Dman&qux=", nil)
+					return req
+				},
+			},
+			want: "bat-man",
+		},
+		{
+			name: "query_param_empty_value",
+			args: args{
+				identifier: `@query-param;name="qux"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value&foo=bar&baz=bat%2Dman&qux=", nil)
+					return req
+				},
+			},
+			want: "",
+		},
+		{
+			name: "query_param_encoded_value",
+			args: args{
+				identifier: `@query-param;name="var"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/parameters?var=this%20is%20a%20big%0Avalue&bar=with+plus+whitespace&fa%C3%A7ade%22%3A%20=something", nil)
+					return req
+				},
+			},
+			want: "this%20is%20a%20big%0Avalue",
+		},
+		{
+			name: "query_param_plus_whitespace",
+			args: args{
+				identifier: `@query-param;name="bar"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/parameters?var=this%20is%20a%20big%0Avalue&bar=with+plus+whitespace&fa%C3%A7ade%22%3A%20=something", nil)
+					return req
+				},
+			},
+			want: "with%20plus%20whitespace",
+		},
+		{
+			name: "query_param_encoded_name",
+			args: args{
+				identifier: `@query-param;name="fa%C3%A7ade%22%3A%20"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/parameters?var=this%20is%20a%20big%0Avalue&bar=with+plus+whitespace&fa%C3%A7ade%22%3A%20=something", nil)
+					return req
+				},
+			},
+			want: "something",
+		},
+		{
+			name: "query_param_missing",
+			args: args{
+				identifier: `@query-param;name="missing"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "query_param_repeated",
+			args: args{
+				identifier: `@query-param;name="param"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value&param=other", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "query_param_without_name",
+			args: args{
+				identifier: `@query-param`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "name_param_on_other_component",
+			args: args{
+				identifier: `@path;name="param"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com/path?param=value", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "header_value",
 			args: args{
@@ -100,6 +256,148 @@ func Test_getComponentValue(t *testing.T) {
 			},
 			want: "max-age=60, must-revalidate",
 		},
+		{
+			name: "structured_field",
+			args: args{
+				identifier: "cache-control;sf",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Add("Cache-Control", "max-age=60,    must-revalidate")
+					return req
+				},
+			},
+			want: "max-age=60, must-revalidate",
+		},
+		{
+			name: "dictionary_member",
+			args: args{
+				identifier: `priority;key="u"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Add("Priority", "u=1, i")
+					return req
+				},
+			},
+			want: "1",
+		},
+		{
+			name: "signature_member",
+			args: args{
+				identifier: `signature;key="sig1"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Add("Signature", "sig1=:YWJj:, proxy=:ZGVm:")
+					return req
+				},
+			},
+			want: ":YWJj:",
+		},
+		{
+			name: "signature_input_member",
+			args: args{
+				identifier: `signature-input;key="sig1"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Add("Signature-Input", `sig1=("@method" "@authority");created=1618884475;keyid="test-key-ecc-p256"`)
+					req.Header.Add("Signature-Input", `proxy=("@method");created=1618884480`)
+					return req
+				},
+			},
+			want: `("@method" "@authority");created=1618884475;keyid="test-key-ecc-p256"`,
+		},
+		{
+			name: "signature_member_missing",
+			args: args{
+				identifier: `signature;key="sig2"`,
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Add("Signature", "sig1=:YWJj:")
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bs_param_cannot_be_combined_with_sf",
+			args: args{
+				identifier: "cache-control;bs;sf",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Add("Cache-Control", "max-age=60")
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sf_param_is_invalid_on_derived_component",
+			args: args{
+				identifier: "@method;sf",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "req_param_is_invalid_on_request",
+			args: args{
+				identifier: "@method;req",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trailer_field",
+			args: args{
+				identifier: "content-digest;tr",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Set("Content-Digest", "sha-256=:header:")
+					req.Trailer = http.Header{"Content-Digest": []string{"sha-256=:trailer:"}}
+					return req
+				},
+			},
+			want: "sha-256=:trailer:",
+		},
+		{
+			name: "trailer_field_missing",
+			args: args{
+				identifier: "x-checksum;tr",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					req.Header.Set("X-Checksum", "foo")
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tr_param_is_invalid_on_derived_component",
+			args: args{
+				identifier: "@method;tr",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown_param",
+			args: args{
+				identifier: "@method;foo",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", nil)
+					return req
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "obsolete_line_folding_is_removed",
 			args: args{
@@ -125,10 +423,61 @@ func Test_getComponentValue(t *testing.T) {
 			},
 			want: "Obsolete line folding.",
 		},
+		{
+			name: "content_length_unknown",
+			args: args{
+				identifier: "content-length",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", strings.NewReader("hello"))
+					req.ContentLength = -1
+					return req
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "repr_digest",
+			args: args{
+				identifier: "repr-digest",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", strings.NewReader(`{"hello": "world"}`))
+					return req
+				},
+				digester: contentdigest.SHA256,
+			},
+			want: "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:",
+		},
+		{
+			name: "repr_digest_uses_header",
+			args: args{
+				identifier: "repr-digest",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", strings.NewReader(`{"hello"`))
+					req.Header.Set("Content-Range", "bytes 0-7/18")
+					req.Header.Set("Repr-Digest", "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:")
+					return req
+				},
+				digester: contentdigest.SHA256,
+			},
+			want: "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:",
+		},
+		{
+			name: "repr_digest_partial_representation",
+			args: args{
+				identifier: "repr-digest",
+				r: func() *http.Request {
+					req, _ := http.NewRequest("POST", "https://example.com", strings.NewReader(`{"hello"`))
+					req.Header.Set("Content-Range", "bytes 0-7/18")
+					return req
+				},
+				digester: contentdigest.SHA256,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getComponentValue(tt.args.identifier, nil, tt.args.r(), tt.args.digester)
+			got, err := getComponentValue(tt.args.identifier, nil, tt.args.r(), tt.args.digester, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getComponentValue() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -159,3 +508,154 @@ func Test_isLower(t *testing.T) {
 		})
 	}
 }
+
+func Test_getResponseComponentValue(t *testing.T) {
+	type args struct {
+		identifier     string
+		res            func() *http.Response
+		digester       contentdigest.Digester
+		declaredDigest bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "status",
+			args: args{
+				identifier: "@status",
+				res: func() *http.Response {
+					return &http.Response{StatusCode: 200}
+				},
+			},
+			want: "200",
+		},
+		{
+			name: "header_value",
+			args: args{
+				identifier: "content-type",
+				res: func() *http.Response {
+					res := &http.Response{StatusCode: 200, Header: http.Header{}}
+					res.Header.Add("Content-Type", "application/json")
+					return res
+				},
+			},
+			want: "application/json",
+		},
+		{
+			name: "content_length",
+			args: args{
+				identifier: "content-length",
+				res: func() *http.Response {
+					return &http.Response{StatusCode: 200, ContentLength: 18}
+				},
+			},
+			want: "18",
+		},
+		{
+			name: "unknown_content_length",
+			args: args{
+				identifier: "content-length",
+				res: func() *http.Response {
+					return &http.Response{StatusCode: 200, ContentLength: -1}
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "content_digest",
+			args: args{
+				identifier: "content-digest",
+				res: func() *http.Response {
+					return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"hello": "world"}`))}
+				},
+				digester: contentdigest.SHA256,
+			},
+			want: "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:",
+		},
+		{
+			name: "declared_content_digest",
+			args: args{
+				identifier: "content-digest",
+				res: func() *http.Response {
+					return &http.Response{
+						StatusCode: 200,
+						Header:     http.Header{"Content-Digest": {"sha-512=:YWJj:"}},
+						Body:       io.NopCloser(strings.NewReader(`{"hello": "world"}`)),
+					}
+				},
+				digester:       contentdigest.SHA256,
+				declaredDigest: true,
+			},
+			want: "sha-512=:YWJj:",
+		},
+		{
+			name: "related_request_method",
+			args: args{
+				identifier: "@method;req",
+				res: func() *http.Response {
+					req, _ := http.NewRequest("POST", "https://example.com/foo", nil)
+					return &http.Response{StatusCode: 200, Request: req}
+				},
+			},
+			want: "POST",
+		},
+		{
+			name: "related_request_target_uri",
+			args: args{
+				identifier: "@target-uri;req",
+				res: func() *http.Response {
+					req, _ := http.NewRequest("POST", "https://example.com/foo", nil)
+					return &http.Response{StatusCode: 200, Request: req}
+				},
+			},
+			want: "https://example.com/foo",
+		},
+		{
+			name: "related_request_header",
+			args: args{
+				identifier: "content-type;req",
+				res: func() *http.Response {
+					req, _ := http.NewRequest("POST", "https://example.com/foo", nil)
+					req.Header.Set("Content-Type", "application/json")
+					return &http.Response{StatusCode: 200, Request: req, Header: http.Header{}}
+				},
+			},
+			want: "application/json",
+		},
+		{
+			name: "related_request_missing",
+			args: args{
+				identifier: "@method;req",
+				res: func() *http.Response {
+					return &http.Response{StatusCode: 200}
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "request_components_are_not_available",
+			args: args{
+				identifier: "@method",
+				res: func() *http.Response {
+					return &http.Response{StatusCode: 200}
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getResponseComponentValue(tt.args.identifier, tt.args.res(), tt.args.digester, tt.args.declaredDigest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getResponseComponentValue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("getResponseComponentValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
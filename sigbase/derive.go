@@ -11,9 +11,38 @@ import (
 // Derive a signature base.
 //
 // When using this in the client context, 'w' may be set to nil.
+//
+// If any trailer fields are covered using the 'tr' parameter,
+// the request body is read into memory so that req.Trailer is populated.
 func Derive(params sigparams.Params, w http.ResponseWriter, req *http.Request, digester contentdigest.Digester) (*Base, error) {
+	return derive(params, w, req, digester, false)
+}
+
+// DeriveWithDeclaredDigest derives a signature base using the values of the
+// Content-Digest and Repr-Digest headers for the 'content-digest' and 'repr-digest'
+// components, rather than reading and hashing the request body.
+//
+// The caller is responsible for verifying the request body against the
+// declared digest, such as by using contentdigest.Digester.NewVerifyingReader.
+//
+// If any trailer fields are covered using the 'tr' parameter,
+// the request body is still read into memory so that req.Trailer is populated.
+func DeriveWithDeclaredDigest(params sigparams.Params, w http.ResponseWriter, req *http.Request, digester contentdigest.Digester) (*Base, error) {
+	return derive(params, w, req, digester, true)
+}
+
+func derive(params sigparams.Params, w http.ResponseWriter, req *http.Request, digester contentdigest.Digester, declaredDigest bool) (*Base, error) {
 	base := New()
 
+	if coversTrailers(params) {
+		// trailers are only available once the body has been read to EOF.
+		// HashRequest reads the body into memory and replaces req.Body.
+		_, err := digester.HashRequest(w, req)
+		if err != nil {
+			return nil, fmt.Errorf("reading request body to obtain trailers: %w", err)
+		}
+	}
+
 	// For each message component item in the covered components set (in order):
 	for _, cc := range params.CoveredComponents {
 		// If the component identifier (including its parameters) has already been added to the signature base, produce an error.
@@ -21,20 +50,124 @@ func Derive(params sigparams.Params, w http.ResponseWriter, req *http.Request, d
 			return nil, fmt.Errorf("the covered component %q has already been added to the signature base: ensure that it is not repeated multiple times in signer.CoveredComponents", cc)
 		}
 
-		val, err := getComponentValue(cc, w, req, digester)
+		val, err := getComponentValue(cc, w, req, digester, declaredDigest)
 		if err != nil {
 			return nil, fmt.Errorf("identifier %q %q: %w", cc, val, err)
 		}
 
 		base.Values[cc] = val
 
-		if cc[0] != '@' && cc != "content-digest" && cc != "content-length" {
-			// add the header value to the list of covered headers
-			for _, v := range req.Header.Values(cc) {
-				base.Header.Add(cc, v)
-			}
+		base.addCoveredHeader(cc, req.Header, req.Trailer)
+	}
+
+	return base, nil
+}
+
+// DeriveResponse derives a signature base for a HTTP response.
+//
+// res.Request, if set, is the request which the response was sent in reply to.
+func DeriveResponse(params sigparams.Params, res *http.Response, digester contentdigest.Digester) (*Base, error) {
+	return deriveResponse(params, res, digester, false)
+}
+
+// DeriveResponseWithDeclaredDigest derives a signature base for a HTTP response
+// using the value of the Content-Digest header for the 'content-digest' component,
+// rather than reading and hashing the response body.
+//
+// The caller is responsible for verifying the response body against the
+// declared digest, such as by using contentdigest.Digester.VerifyResponse.
+func DeriveResponseWithDeclaredDigest(params sigparams.Params, res *http.Response, digester contentdigest.Digester) (*Base, error) {
+	return deriveResponse(params, res, digester, true)
+}
+
+func deriveResponse(params sigparams.Params, res *http.Response, digester contentdigest.Digester, declaredDigest bool) (*Base, error) {
+	base := New()
+
+	if coversTrailers(params) {
+		_, err := digester.HashResponse(res)
+		if err != nil {
+			return nil, fmt.Errorf("reading response body to obtain trailers: %w", err)
 		}
 	}
 
+	// For each message component item in the covered components set (in order):
+	for _, cc := range params.CoveredComponents {
+		// If the component identifier (including its parameters) has already been added to the signature base, produce an error.
+		if _, ok := base.Values[cc]; ok {
+			return nil, fmt.Errorf("the covered component %q has already been added to the signature base: ensure that it is not repeated multiple times in signer.CoveredComponents", cc)
+		}
+
+		val, err := getResponseComponentValue(cc, res, digester, declaredDigest)
+		if err != nil {
+			return nil, fmt.Errorf("identifier %q %q: %w", cc, val, err)
+		}
+
+		base.Values[cc] = val
+
+		base.addCoveredHeader(cc, res.Header, res.Trailer)
+	}
+
 	return base, nil
 }
+
+// coversTrailers returns true if any of the covered components
+// have the 'tr' parameter.
+func coversTrailers(params sigparams.Params) bool {
+	for _, cc := range params.CoveredComponents {
+		c, err := sigparams.ParseComponent(cc)
+		if err != nil {
+			continue
+		}
+		if c.HasParam("tr") {
+			return true
+		}
+	}
+	return false
+}
+
+// addCoveredHeader adds the values for a HTTP header field to the list of
+// covered headers, if the component identifier refers to a header field
+// on the target message.
+//
+// Components with the 'tr' parameter are added to the covered trailers.
+func (b *Base) addCoveredHeader(identifier string, h http.Header, trailer http.Header) {
+	c, err := sigparams.ParseComponent(identifier)
+	if err != nil {
+		return
+	}
+
+	// components with the 'req' parameter refer to the related request,
+	// rather than the target message.
+	if c.Name[0] == '@' || c.HasParam("req") {
+		return
+	}
+
+	if c.HasParam("tr") {
+		if b.Trailer == nil {
+			b.Trailer = http.Header{}
+		}
+		addValues(b.Trailer, c.Name, trailer)
+		return
+	}
+
+	if c.Name == "content-digest" || c.Name == "content-length" {
+		return
+	}
+
+	addValues(b.Header, c.Name, h)
+}
+
+// addValues copies the values for the field name from src to dst,
+// if they have not already been copied.
+func addValues(dst http.Header, name string, src http.Header) {
+	key := http.CanonicalHeaderKey(name)
+
+	// the field may be covered multiple times with different parameters.
+	if _, ok := dst[key]; ok {
+		return
+	}
+
+	for _, v := range src.Values(name) {
+		dst.Add(name, v)
+	}
+}
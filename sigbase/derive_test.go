@@ -2,6 +2,7 @@ package sigbase
 
 import (
 	"bytes"
+	"io"
 	"net/http"
 	"testing"
 	"time"
@@ -102,6 +103,38 @@ func TestDerive(t *testing.T) {
 				Header: http.Header{},
 			},
 		},
+		{
+			name: "with_trailers",
+			params: sigparams.Params{
+				CoveredComponents: []string{"@method", "content-digest;tr", "x-checksum;tr"},
+			},
+			digester: contentdigest.SHA256,
+			req: func() (*http.Request, error) {
+				req, err := http.NewRequest("POST", "https://example.com", bytes.NewBufferString("hello"))
+				if err != nil {
+					return nil, err
+				}
+				req.Header.Set("X-Checksum", "header")
+				req.Trailer = http.Header{
+					"Content-Digest": {"sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:"},
+					"X-Checksum":     {"trailer"},
+					"X-Uncovered":    {"foo"},
+				}
+				return req, nil
+			},
+			want: &Base{
+				Values: map[string]string{
+					"@method":           "POST",
+					"content-digest;tr": "sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:",
+					"x-checksum;tr":     "trailer",
+				},
+				Header: http.Header{},
+				Trailer: http.Header{
+					"Content-Digest": {"sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:"},
+					"X-Checksum":     {"trailer"},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -123,3 +156,94 @@ func TestDerive(t *testing.T) {
 		})
 	}
 }
+
+func TestDeriveWithDeclaredDigest(t *testing.T) {
+	// the declared digest does not match the body, as the
+	// body is not read when deriving the signature base.
+	req, err := http.NewRequest("POST", "https://example.com", bytes.NewBufferString("hello"))
+	if err != nil {
+		t.Fatalf("error constructing test HTTP request: %s", err)
+	}
+	req.Header.Set("Content-Digest", "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:")
+
+	params := sigparams.Params{
+		CoveredComponents: []string{"@method", "content-length", "content-digest"},
+	}
+
+	got, err := DeriveWithDeclaredDigest(params, nil, req, contentdigest.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Base{
+		Values: map[string]string{
+			"@method":        "POST",
+			"content-length": "5",
+			"content-digest": "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:",
+		},
+		Header: http.Header{},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DeriveWithDeclaredDigest() mismatch (-want +got):\n%s", diff)
+	}
+
+	// the body has not been read.
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hello" {
+		t.Fatalf("request body was read when deriving the signature base, got %q", body)
+	}
+
+	// the Content-Digest header is required.
+	req.Header.Del("Content-Digest")
+	_, err = DeriveWithDeclaredDigest(params, nil, req, contentdigest.SHA256)
+	if err == nil {
+		t.Fatal("expected an error when the Content-Digest header is missing")
+	}
+}
+
+func TestDeriveResponse(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://example.com/foo", nil)
+	if err != nil {
+		t.Fatalf("error constructing test HTTP request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res := &http.Response{
+		StatusCode: 201,
+		Header: http.Header{
+			"Content-Type": {"text/plain"},
+			"X-Uncovered":  {"foo"},
+		},
+		Request: req,
+	}
+
+	params := sigparams.Params{
+		CoveredComponents: []string{"@status", "content-type", "@method;req", "content-type;req"},
+	}
+
+	got, err := DeriveResponse(params, res, contentdigest.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Base{
+		Values: map[string]string{
+			"@status":          "201",
+			"content-type":     "text/plain",
+			"@method;req":      "POST",
+			"content-type;req": "application/json",
+		},
+		// headers on the related request are not included.
+		Header: http.Header{
+			"Content-Type": {"text/plain"},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DeriveResponse() mismatch (-want +got):\n%s", diff)
+	}
+}
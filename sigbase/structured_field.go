@@ -0,0 +1,166 @@
+package sigbase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/dunglas/httpsfv"
+)
+
+// StructuredFieldType is the top-level type of an HTTP structured field.
+//
+// See: https://www.rfc-editor.org/rfc/rfc8941.html#section-3
+type StructuredFieldType int
+
+const (
+	StructuredFieldItem StructuredFieldType = iota + 1
+	StructuredFieldList
+	StructuredFieldDictionary
+)
+
+// StructuredFields are the HTTP fields which are known to be structured fields.
+// The names are lowercase.
+//
+// The type of a field must be known in order to cover the field
+// with the 'sf' component parameter. Applications may add entries
+// for their own structured fields.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.1
+var StructuredFields = map[string]StructuredFieldType{
+	"accept-signature":    StructuredFieldDictionary,
+	"cache-control":       StructuredFieldDictionary,
+	"cache-status":        StructuredFieldList,
+	"content-digest":      StructuredFieldDictionary,
+	"priority":            StructuredFieldDictionary,
+	"proxy-status":        StructuredFieldList,
+	"repr-digest":         StructuredFieldDictionary,
+	"signature":           StructuredFieldDictionary,
+	"signature-input":     StructuredFieldDictionary,
+	"want-content-digest": StructuredFieldDictionary,
+	"want-repr-digest":    StructuredFieldDictionary,
+}
+
+// getFieldValue canonicalizes the values of a HTTP field, processing the
+// 'sf', 'key' and 'bs' component parameters if they are present.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1
+func getFieldValue(c sigparams.Component, values []string) (string, error) {
+	if c.HasParam("bs") {
+		return encodeByteSequence(values)
+	}
+
+	if c.HasParam("key") {
+		return getDictionaryMember(c, values)
+	}
+
+	if c.HasParam("sf") {
+		return serializeStructuredField(c.Name, values)
+	}
+
+	return canonicalizeField(values)
+}
+
+// serializeStructuredField parses the field values as a structured field
+// and re-serializes it in its canonical form.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.1
+func serializeStructuredField(name string, values []string) (string, error) {
+	if len(values) == 0 {
+		return "", errors.New("HTTP header was empty")
+	}
+
+	sfType, ok := StructuredFields[name]
+	if !ok {
+		return "", fmt.Errorf("the 'sf' parameter cannot be used because %q is not a known structured field: add it to sigbase.StructuredFields", name)
+	}
+
+	var (
+		sfv httpsfv.StructuredFieldValue
+		err error
+	)
+
+	switch sfType {
+	case StructuredFieldItem:
+		sfv, err = httpsfv.UnmarshalItem(values)
+	case StructuredFieldList:
+		sfv, err = httpsfv.UnmarshalList(values)
+	case StructuredFieldDictionary:
+		sfv, err = httpsfv.UnmarshalDictionary(values)
+	default:
+		return "", fmt.Errorf("unknown structured field type %v for field %q", sfType, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("parsing %q as a structured field: %w", name, err)
+	}
+
+	return httpsfv.Marshal(sfv)
+}
+
+// getDictionaryMember parses the field values as a dictionary structured field
+// and returns the serialized member value specified by the 'key' parameter.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.2
+func getDictionaryMember(c sigparams.Component, values []string) (string, error) {
+	keyParam, _ := c.Params.Get("key")
+	key, ok := keyParam.(string)
+	if !ok {
+		return "", errors.New("the 'key' parameter must be a string")
+	}
+
+	if sfType, ok := StructuredFields[c.Name]; ok && sfType != StructuredFieldDictionary {
+		return "", fmt.Errorf("the 'key' parameter cannot be used because %q is not a dictionary structured field", c.Name)
+	}
+
+	if len(values) == 0 {
+		return "", errors.New("HTTP header was empty")
+	}
+
+	dict, err := httpsfv.UnmarshalDictionary(values)
+	if err != nil {
+		return "", fmt.Errorf("parsing %q as a dictionary structured field: %w", c.Name, err)
+	}
+
+	member, ok := dict.Get(key)
+	if !ok {
+		return "", fmt.Errorf("dictionary member %q was not present in %q", key, c.Name)
+	}
+
+	switch m := member.(type) {
+	case httpsfv.Item:
+		return httpsfv.Marshal(m)
+	case httpsfv.InnerList:
+		return httpsfv.Marshal(m)
+	}
+
+	return "", fmt.Errorf("unexpected dictionary member type %T", member)
+}
+
+// encodeByteSequence wraps each of the field values as a byte sequence.
+// This allows field values containing non-ASCII characters to be covered.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.3
+func encodeByteSequence(values []string) (string, error) {
+	if len(values) == 0 {
+		return "", errors.New("HTTP header was empty")
+	}
+
+	encoded := make([]string, len(values))
+
+	for i, val := range values {
+		// Strip leading and trailing whitespace from the field value.
+		val = strings.Trim(val, " \t")
+
+		// Encode the bytes of the resulting field value as a Byte Sequence.
+		item, err := httpsfv.Marshal(httpsfv.NewItem([]byte(val)))
+		if err != nil {
+			return "", err
+		}
+		encoded[i] = item
+	}
+
+	// Concatenate the list of values with a single comma (",")
+	// and a single space (" ") between each item.
+	return strings.Join(encoded, ", "), nil
+}
@@ -0,0 +1,133 @@
+package sigbase
+
+import (
+	"testing"
+
+	"github.com/common-fate/httpsig/sigparams"
+)
+
+func Test_getFieldValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		values     []string
+		want       string
+		wantErr    bool
+	}{
+		// examples taken from https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.1
+		{
+			name:       "raw_value",
+			identifier: "cache-control",
+			values:     []string{"max-age=60,    must-revalidate"},
+			want:       "max-age=60,    must-revalidate",
+		},
+		{
+			name:       "sf_dictionary",
+			identifier: "cache-control;sf",
+			values:     []string{"max-age=60,    must-revalidate"},
+			want:       "max-age=60, must-revalidate",
+		},
+		{
+			name:       "sf_multiple_values",
+			identifier: "priority;sf",
+			values:     []string{"u=1", "  i"},
+			want:       "u=1, i",
+		},
+		{
+			name:       "sf_list",
+			identifier: "cache-status;sf",
+			values:     []string{`ExampleCache;  hit`},
+			want:       `ExampleCache;hit`,
+		},
+		{
+			name:       "sf_unknown_field",
+			identifier: "x-unknown;sf",
+			values:     []string{"a=1"},
+			wantErr:    true,
+		},
+		{
+			name:       "sf_invalid_value",
+			identifier: "priority;sf",
+			values:     []string{"???"},
+			wantErr:    true,
+		},
+		// examples taken from https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.2
+		{
+			name:       "key_item",
+			identifier: `example-dict;key="a"`,
+			values:     []string{" a=1, b=2;x=1;y=2, c=(a   b    c), d"},
+			want:       "1",
+		},
+		{
+			name:       "key_item_with_params",
+			identifier: `example-dict;key="b"`,
+			values:     []string{" a=1, b=2;x=1;y=2, c=(a   b    c), d"},
+			want:       "2;x=1;y=2",
+		},
+		{
+			name:       "key_inner_list",
+			identifier: `example-dict;key="c"`,
+			values:     []string{" a=1, b=2;x=1;y=2, c=(a   b    c), d"},
+			want:       "(a b c)",
+		},
+		{
+			name:       "key_boolean",
+			identifier: `example-dict;key="d"`,
+			values:     []string{" a=1, b=2;x=1;y=2, c=(a   b    c), d"},
+			want:       "?1",
+		},
+		{
+			name:       "key_missing_member",
+			identifier: `example-dict;key="e"`,
+			values:     []string{" a=1, b=2;x=1;y=2, c=(a   b    c), d"},
+			wantErr:    true,
+		},
+		{
+			name:       "key_on_list_field",
+			identifier: `cache-status;key="a"`,
+			values:     []string{"ExampleCache; hit"},
+			wantErr:    true,
+		},
+		{
+			name:       "key_must_be_string",
+			identifier: `example-dict;key=1`,
+			values:     []string{"a=1"},
+			wantErr:    true,
+		},
+		// examples taken from https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.3
+		{
+			name:       "byte_sequence",
+			identifier: "example-header;bs",
+			values:     []string{"value, with, lots", "of, commas"},
+			want:       ":dmFsdWUsIHdpdGgsIGxvdHM=:, :b2YsIGNvbW1hcw==:",
+		},
+		{
+			name:       "byte_sequence_single_value",
+			identifier: "example-header;bs",
+			values:     []string{"value, with, lots, of, commas"},
+			want:       ":dmFsdWUsIHdpdGgsIGxvdHMsIG9mLCBjb21tYXM=:",
+		},
+		{
+			name:       "byte_sequence_non_ascii",
+			identifier: "x-filename;bs",
+			values:     []string{" résumé.pdf "},
+			want:       ":csOpc3Vtw6kucGRm:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := sigparams.ParseComponent(tt.identifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := getFieldValue(c, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getFieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getFieldValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package signer
+
+import (
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+)
+
+// sentSignature is the label and parameters of the
+// signature which was sent with a request.
+type sentSignature struct {
+	label  string
+	params sigparams.Params
+}
+
+// acceptSignatureRetry prepares to retry a request which was rejected
+// by the server, using the signature requested in the Accept-Signature
+// field of the response.
+//
+// It returns a copy of the transport which covers the requested components
+// and uses the requested label, and a copy of the request to send.
+// The request is only retried if the server requested components which
+// were not covered by the signature which was sent, as otherwise the
+// request was rejected for another reason and would be rejected again.
+// If the requested signature cannot be created by the transport, or the
+// request body cannot be sent again, ok is false.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-5.1
+func (t *Transport) acceptSignatureRetry(req *http.Request, res *http.Response, sent *sentSignature) (retry *Transport, retryReq *http.Request, ok bool) {
+	// the signature may not have been sent if the
+	// request body was not read to EOF.
+	if sent == nil || sent.label == "" {
+		return nil, nil, false
+	}
+
+	requests, err := sigset.UnmarshalAccept(res.Header)
+	if err != nil || len(requests) == 0 {
+		return nil, nil, false
+	}
+
+	label, accept := t.findAcceptable(requests)
+	if accept == nil {
+		return nil, nil, false
+	}
+
+	covered := map[string]bool{}
+	for _, cc := range sent.params.CoveredComponents {
+		covered[cc] = true
+	}
+
+	var missing []string
+	for _, cc := range accept.CoveredComponents {
+		if !covered[cc] {
+			missing = append(missing, cc)
+			covered[cc] = true
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil, nil, false
+	}
+
+	// the requested label must not be used by an existing signature on the request.
+	existing, err := sigset.Unmarshal(req)
+	if err != nil || existing.Get(label) != nil {
+		return nil, nil, false
+	}
+
+	retryReq = cloneRequest(req)
+
+	// the original request body has been consumed,
+	// so we need to obtain a new copy of it.
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, nil, false
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, false
+		}
+		retryReq.Body = body
+	}
+
+	retry = new(Transport)
+	*retry = *t
+	retry.RetryOnAcceptSignature = false
+
+	// cover the requested components in addition
+	// to the components already covered by the transport.
+	retry.CoveredComponents = append(append([]string(nil), t.CoveredComponents...), missing...)
+
+	// sign using the label requested by the server.
+	retry.Labeler = LabelerFunc(func(int) string {
+		return label
+	})
+
+	return retry, retryReq, true
+}
+
+// findAcceptable returns the label and the first requested signature, ordered by label,
+// which can be created using the transport's key, tag and algorithm.
+func (t *Transport) findAcceptable(requests map[string]*sigparams.AcceptSignature) (string, *sigparams.AcceptSignature) {
+	labels := make([]string, 0, len(requests))
+	for k := range requests {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		a := requests[label]
+
+		if a.Tag != "" && a.Tag != t.Tag {
+			continue
+		}
+
+		if a.KeyID != "" && a.KeyID != t.KeyID {
+			continue
+		}
+
+		if a.Alg != "" && (t.Alg == nil || a.Alg != t.Alg.Type()) {
+			continue
+		}
+
+		return label, a
+	}
+
+	return "", nil
+}
+
+// maxDiscardBytes is the maximum number of bytes of a response
+// body to read before the request is retried.
+const maxDiscardBytes = 64 << 10
+
+// discardBody reads and closes a response body, so that the underlying
+// connection can be reused. At most maxDiscardBytes are read, so that
+// a server can't keep the client reading a response indefinitely.
+func discardBody(res *http.Response) {
+	_, _ = io.CopyN(io.Discard, res.Body, maxDiscardBytes)
+	_ = res.Body.Close()
+}
@@ -0,0 +1,127 @@
+package signer
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTransport_acceptSignatureRetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptSig      string
+		sent           *sentSignature
+		existingInput  string
+		wantOK         bool
+		wantComponents []string
+		wantLabel      string
+	}{
+		{
+			name:           "missing_component",
+			acceptSig:      `req=("@method" "x-request-id");tag="foo"`,
+			sent:           &sentSignature{label: "sig1", params: sigparams.Params{CoveredComponents: []string{"@method"}}},
+			wantOK:         true,
+			wantComponents: []string{"@method", "x-request-id"},
+			wantLabel:      "req",
+		},
+		{
+			name:      "components_already_covered",
+			acceptSig: `sig1=("@method");tag="foo"`,
+			sent:      &sentSignature{label: "sig1", params: sigparams.Params{CoveredComponents: []string{"@method"}}},
+			wantOK:    false,
+		},
+		{
+			name:      "different_tag",
+			acceptSig: `sig1=("@method" "x-request-id");tag="bar"`,
+			sent:      &sentSignature{label: "sig1", params: sigparams.Params{CoveredComponents: []string{"@method"}}},
+			wantOK:    false,
+		},
+		{
+			name:      "signature_not_sent",
+			acceptSig: `sig1=("@method" "x-request-id");tag="foo"`,
+			sent:      &sentSignature{},
+			wantOK:    false,
+		},
+		{
+			name:          "requested_label_in_use",
+			acceptSig:     `proxy=("@method" "x-request-id");tag="foo"`,
+			sent:          &sentSignature{label: "sig1", params: sigparams.Params{CoveredComponents: []string{"@method"}}},
+			existingInput: `proxy=("@method");keyid="proxy"`,
+			wantOK:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &Transport{
+				Tag:                    "foo",
+				CoveredComponents:      []string{"@method"},
+				RetryOnAcceptSignature: true,
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.existingInput != "" {
+				req.Header.Set("Signature-Input", tt.existingInput)
+				req.Header.Set("Signature", "proxy=:YWJj:")
+			}
+
+			res := &http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Header:     http.Header{"Accept-Signature": {tt.acceptSig}},
+			}
+
+			retry, _, ok := tr.acceptSignatureRetry(req, res, tt.sent)
+			if ok != tt.wantOK {
+				t.Fatalf("acceptSignatureRetry() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if retry.RetryOnAcceptSignature {
+				t.Errorf("expected the retry to disable RetryOnAcceptSignature")
+			}
+
+			if diff := cmp.Diff(tt.wantComponents, retry.CoveredComponents); diff != "" {
+				t.Errorf("CoveredComponents mismatch (-want +got):\n%s", diff)
+			}
+
+			if got := retry.Labeler.Label(0); got != tt.wantLabel {
+				t.Errorf("label = %q, want %q", got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+// endlessBody is a response body which never ends.
+type endlessBody struct {
+	read   int64
+	closed bool
+}
+
+func (b *endlessBody) Read(p []byte) (int, error) {
+	b.read += int64(len(p))
+	return len(p), nil
+}
+
+func (b *endlessBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func Test_discardBody(t *testing.T) {
+	body := &endlessBody{}
+
+	discardBody(&http.Response{Body: body})
+
+	if body.read > maxDiscardBytes {
+		t.Errorf("read %d bytes, want at most %d", body.read, maxDiscardBytes)
+	}
+	if !body.closed {
+		t.Error("expected the body to be closed")
+	}
+}
@@ -0,0 +1,76 @@
+package signer
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigparams"
+)
+
+// coversContentLength returns true if the 'content-length'
+// component is included in the covered components.
+func coversContentLength(coveredComponents []string) bool {
+	for _, cc := range coveredComponents {
+		c, err := sigparams.ParseComponent(cc)
+		if err != nil {
+			continue
+		}
+		if c.Name == "content-length" && !c.HasParam("req") {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyLengthIsUnknown returns true if the length of the request body is unknown.
+//
+// For client requests, a ContentLength of 0 with a non-nil Body is also
+// treated as unknown, and the request is sent using chunked encoding.
+// See: https://pkg.go.dev/net/http#Request.ContentLength
+func bodyLengthIsUnknown(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return false
+	}
+	return req.ContentLength <= 0
+}
+
+// maxBodyBytes returns the limit of bytes to read when measuring a request body.
+func (t *Transport) maxBodyBytes() int64 {
+	if t.MaxBodyBytes > 0 {
+		return t.MaxBodyBytes
+	}
+	if n := t.contentDigest().MaxBytes; n > 0 {
+		return n
+	}
+	return contentdigest.DefaultMaxBytes
+}
+
+// measureBody reads the request body into memory and sets req.ContentLength,
+// so that the request is sent with a Content-Length header.
+//
+// A *http.MaxBytesError is returned if the body is larger than maxBytes.
+func measureBody(req *http.Request, maxBytes int64) error {
+	defer req.Body.Close()
+
+	data, err := io.ReadAll(http.MaxBytesReader(nil, req.Body, maxBytes))
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = int64(len(data))
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	if len(data) == 0 {
+		// net/http treats a zero ContentLength with a non-nil
+		// body as unknown, so the body is removed.
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+	}
+
+	return nil
+}
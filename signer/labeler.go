@@ -1,5 +1,12 @@
 package signer
 
+import (
+	"fmt"
+
+	"github.com/common-fate/httpsig/signature"
+	"github.com/common-fate/httpsig/sigset"
+)
+
 // Labeler generate a label to be used for a HTTP signature.
 //
 // An HTTP message signature is identified by a label within an HTTP message.
@@ -10,3 +17,31 @@ package signer
 type Labeler interface {
 	Label(existingCount int) string
 }
+
+// LabelerFunc is an adapter to allow the use of
+// ordinary functions as a Labeler.
+type LabelerFunc func(existingCount int) string
+
+// Label calls f(existingCount).
+func (f LabelerFunc) Label(existingCount int) string {
+	return f(existingCount)
+}
+
+// addToSet adds a signature to the set, using the Labeler
+// to choose the label if one has been provided.
+//
+// The label is returned so that it can be used when including
+// the signature in trailers.
+func (t *Transport) addToSet(set *sigset.Set, ms *signature.Message) (string, error) {
+	label := set.NextLabel()
+	if t.Labeler != nil {
+		label = t.Labeler.Label(len(set.Messages))
+	}
+
+	err := set.AddWithLabel(label, ms)
+	if err != nil {
+		return "", fmt.Errorf("adding signature to set: %w", err)
+	}
+
+	return label, nil
+}
@@ -0,0 +1,75 @@
+package signer
+
+import (
+	"testing"
+
+	"github.com/common-fate/httpsig/signature"
+	"github.com/common-fate/httpsig/sigset"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTransport_addToSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		labeler  Labeler
+		existing []string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "default",
+			want: "sig1",
+		},
+		{
+			name:     "default_after_existing",
+			existing: []string{"sig1", "proxy"},
+			want:     "sig3",
+		},
+		{
+			name: "labeler",
+			labeler: LabelerFunc(func(existingCount int) string {
+				return "client"
+			}),
+			existing: []string{"sig1"},
+			want:     "client",
+		},
+		{
+			name: "labeler_duplicate",
+			labeler: LabelerFunc(func(existingCount int) string {
+				return "sig1"
+			}),
+			existing: []string{"sig1"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var set sigset.Set
+			for _, label := range tt.existing {
+				err := set.AddWithLabel(label, &signature.Message{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tr := &Transport{Labeler: tt.labeler}
+
+			got, err := tr.addToSet(&set, &signature.Message{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transport.addToSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Transport.addToSet() = %v, want %v", got, tt.want)
+			}
+
+			// the new signature is ordered after the existing signatures.
+			want := append(tt.existing, tt.want)
+			if diff := cmp.Diff(want, set.Labels()); diff != "" {
+				t.Errorf("Set.Labels() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -7,8 +7,14 @@ import (
 )
 
 func (t *Transport) nonce() (string, error) {
-	if t.GetNonce != nil {
-		return t.GetNonce()
+	return getNonce(t.GetNonce)
+}
+
+// getNonce calls the nonce generation function if provided,
+// falling back to a random nonce.
+func getNonce(fn func() (string, error)) (string, error) {
+	if fn != nil {
+		return fn()
 	}
 
 	return randomNonce()
@@ -0,0 +1,437 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/common-fate/httpsig/sigbase"
+	"github.com/common-fate/httpsig/signature"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+)
+
+// ResponseSigner signs outgoing HTTP responses using HTTP Message Signatures,
+// allowing clients to verify that a response was sent by the server.
+//
+// The signature schema adheres to RFC9421.
+// See: https://www.rfc-editor.org/rfc/rfc9421.html
+type ResponseSigner struct {
+	// KeyID is the identifier for the key to use for signing responses.
+	KeyID string
+
+	// Tag is an application-specific tag for the signature as a String value.
+	// This value is used by applications to help identify signatures relevant for specific applications or protocols.
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.3-4.12
+	Tag string
+
+	// Alg is the signing algorithm to use.
+	Alg Algorithm
+
+	// CoveredComponents specify the components of the response
+	// to be covered with the signature.
+	//
+	// The only derived component which may be used on a response is '@status'.
+	//
+	// Components of the request which the response was sent in reply to
+	// can be covered using the 'req' parameter, such as '@method;req'.
+	// This binds the response signature to the request.
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.4
+	//
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-1.1-7.18.1
+	CoveredComponents []string
+
+	// Scheme is the URL scheme used when deriving request components
+	// with the 'req' parameter, such as '@target-uri;req'.
+	//
+	// If empty, the scheme is inferred from the incoming request.
+	// Should be 'https' in production.
+	Scheme string
+
+	// Authority is the HTTP authority used when deriving request components
+	// with the 'req' parameter, such as '@target-uri;req'.
+	//
+	// If empty, the Host of the incoming request is used.
+	Authority string
+
+	// GetNonce can optionally be provided to override the built-in
+	// nonce generation function. If the provided Nonce function
+	// returns an empty string, a nonce will not be included
+	// in the signed response.
+	//
+	// If Nonce is not provided, a random 32 byte string
+	// will be used as the nonce.
+	GetNonce func() (string, error)
+
+	// OnSigningError, if set, is called when a response
+	// could not be signed by the Handler middleware.
+	OnSigningError func(ctx context.Context, err error)
+
+	// OnDeriveSigningString is a hook which can be used to log
+	// the string to sign.
+	//
+	// This can be useful for debugging signature errors,
+	// as you can compare the base signing string between the client
+	// and server.
+	OnDeriveSigningString func(ctx context.Context, stringToSign string)
+}
+
+// SignResponse signs a HTTP response following the process described in https://www.rfc-editor.org/rfc/rfc9421.html#section-3.1.
+//
+// If the 'content-digest' component is covered, the response body is read into memory,
+// and the Content-Digest header of the response is set to the digest of the body,
+// so that the covered field is present in the message.
+func (s *ResponseSigner) SignResponse(res *http.Response) (*signature.Message, error) {
+	if s.Alg == nil {
+		return nil, errors.New("algorithm must not be nil")
+	}
+
+	ctx := context.Background()
+	if res.Request != nil {
+		ctx = res.Request.Context()
+	}
+
+	nonce, err := getNonce(s.GetNonce)
+	if err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	params := sigparams.Params{
+		KeyID:             s.KeyID,
+		Tag:               s.Tag,
+		Alg:               s.Alg.Type(),
+		Created:           getCurrentTime(),
+		CoveredComponents: s.CoveredComponents,
+		Nonce:             nonce,
+	}
+
+	base, err := sigbase.DeriveResponse(params, res, s.Alg.ContentDigest())
+	if err != nil {
+		return nil, fmt.Errorf("deriving signature base: %w", err)
+	}
+
+	// covered fields must be present in the message, so the digest
+	// of the body is sent if it is covered.
+	if digest, ok := base.Values["content-digest"]; ok && digest != "" {
+		res.Header.Set("Content-Digest", digest)
+	}
+
+	stringToSign, err := base.CanonicalString(params)
+	if err != nil {
+		return nil, fmt.Errorf("creating string to sign: %w", err)
+	}
+
+	if s.OnDeriveSigningString != nil {
+		s.OnDeriveSigningString(ctx, stringToSign)
+	}
+
+	sig, err := s.Alg.Sign(ctx, stringToSign)
+	if err != nil {
+		return nil, fmt.Errorf("error signing response: %w", err)
+	}
+
+	output := signature.Message{
+		Input:     params,
+		Signature: sig,
+	}
+
+	return &output, nil
+}
+
+// Handler is a HTTP server middleware which signs the responses
+// written by the next handler.
+//
+// The response is buffered in memory so that the signature can be
+// included in the Signature-Input and Signature headers before the
+// body is sent. If the response cannot be signed, a
+// 500 Internal Server Error is returned instead.
+//
+// If the next handler flushes the response, the response is signed
+// when it is first flushed and the rest of the body is streamed to the client.
+// This is only possible if the signature does not cover the response body:
+// if 'content-digest' or 'repr-digest' is covered, or 'content-length' is
+// covered and the handler has not set the Content-Length header, the response
+// continues to be buffered and the error is passed to OnSigningError.
+func (s *ResponseSigner) Handler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		buf := &responseBuffer{w: w}
+
+		buf.onFlush = func() bool {
+			err := s.canStream(w.Header())
+			if err == nil {
+				err = s.writeSigned(w, r, buf, true)
+				if err == nil {
+					return true
+				}
+				s.writeError(w, r, err)
+				buf.err = err
+				return false
+			}
+
+			// the response is signed once the handler returns.
+			if !buf.flushErrReported && s.OnSigningError != nil {
+				s.OnSigningError(r.Context(), err)
+			}
+			buf.flushErrReported = true
+			return false
+		}
+
+		next.ServeHTTP(buf, r)
+
+		// the response has already been signed and sent.
+		if buf.streaming || buf.err != nil {
+			return
+		}
+
+		err := s.writeSigned(w, r, buf, false)
+		if err != nil {
+			s.writeError(w, r, err)
+		}
+	}
+	return http.HandlerFunc(fn)
+}
+
+// writeError writes a 500 Internal Server Error response
+// if the response could not be signed.
+func (s *ResponseSigner) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if s.OnSigningError != nil {
+		s.OnSigningError(r.Context(), err)
+	}
+	// discard the headers describing the unsigned response body.
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("Signature")
+	h.Del("Signature-Input")
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+}
+
+// canStream returns an error if the response can't be signed until
+// the whole body has been written, because the signature covers the body.
+func (s *ResponseSigner) canStream(header http.Header) error {
+	for _, cc := range s.CoveredComponents {
+		c, err := sigparams.ParseComponent(cc)
+		if err != nil {
+			return err
+		}
+		if c.HasParam("req") || c.HasParam("tr") {
+			continue
+		}
+
+		coversBody := c.Name == "content-digest" || c.Name == "repr-digest" ||
+			(c.Name == "content-length" && header.Get("Content-Length") == "")
+
+		if coversBody {
+			return fmt.Errorf("%w: %q is covered", ErrCannotFlush, cc)
+		}
+	}
+	return nil
+}
+
+// ErrCannotFlush is passed to ResponseSigner.OnSigningError if a handler
+// flushes a response whose body is covered by the signature.
+var ErrCannotFlush = errors.New("the response was not flushed because the signature covers the response body")
+
+// writeSigned signs the buffered response and writes it to w.
+//
+// If streaming is true, the response is signed before the whole body
+// has been written, so the Content-Length header is not set.
+func (s *ResponseSigner) writeSigned(w http.ResponseWriter, r *http.Request, buf *responseBuffer, streaming bool) error {
+	status := buf.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	body := buf.body.Bytes()
+	header := w.Header()
+
+	res := &http.Response{
+		Status:     strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode: status,
+		Proto:      r.Proto,
+		ProtoMajor: r.ProtoMajor,
+		ProtoMinor: r.ProtoMinor,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    s.relatedRequest(r),
+	}
+
+	if bodyAllowedForStatus(status) {
+		// match the behaviour of the Go HTTP server, which sniffs
+		// the content type if it has not been set.
+		if _, haveType := header["Content-Type"]; !haveType && len(body) > 0 {
+			header.Set("Content-Type", http.DetectContentType(body))
+		}
+
+		// the response is fully buffered, so the content length is known
+		// and can be covered by the signature.
+		//
+		// responses to HEAD requests keep the Content-Length set by the
+		// handler, which is the length of the response to a GET request.
+		length := header.Get("Content-Length")
+		if !streaming && (r.Method != http.MethodHead || length == "") {
+			length = strconv.Itoa(len(body))
+			header.Set("Content-Length", length)
+		}
+
+		res.ContentLength = -1
+		if n, err := strconv.ParseInt(length, 10, 64); err == nil {
+			res.ContentLength = n
+		}
+	}
+
+	// parse the existing signature set on the response
+	set, err := sigset.UnmarshalHeader(header)
+	if err != nil {
+		return err
+	}
+
+	ms, err := s.SignResponse(res)
+	if err != nil {
+		return err
+	}
+
+	set.Add(ms)
+
+	err = set.IncludeHeader(header)
+	if err != nil {
+		return fmt.Errorf("including signature in HTTP response: %w", err)
+	}
+
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	_, err = w.Write(body)
+	if err != nil {
+		// the headers have already been sent, so the error
+		// can't be reported to the client.
+		if s.OnSigningError != nil {
+			s.OnSigningError(r.Context(), fmt.Errorf("writing signed response: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// relatedRequest returns a shallow copy of the incoming request
+// with the URL scheme and host set, so that request components
+// with the 'req' parameter match those derived by the client.
+func (s *ResponseSigner) relatedRequest(r *http.Request) *http.Request {
+	u := *r.URL
+
+	switch {
+	case s.Scheme != "":
+		u.Scheme = s.Scheme
+	case u.Scheme == "" && r.TLS != nil:
+		u.Scheme = "https"
+	case u.Scheme == "":
+		u.Scheme = "http"
+	}
+
+	switch {
+	case s.Authority != "":
+		u.Host = s.Authority
+	case u.Host == "":
+		u.Host = r.Host
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = &u
+	return r2
+}
+
+// responseBuffer is a http.ResponseWriter which
+// buffers the response status and body in memory.
+//
+// Headers are written directly to the underlying
+// http.ResponseWriter.
+type responseBuffer struct {
+	w      http.ResponseWriter
+	status int
+	body   bytes.Buffer
+
+	// onFlush is called when the response is first flushed, and
+	// returns true if the response has been signed and sent.
+	onFlush func() bool
+
+	// streaming is true once the response has been signed and sent,
+	// after which writes are passed to the underlying http.ResponseWriter.
+	streaming bool
+
+	// err is set if the response could not be signed when it was flushed.
+	err error
+
+	flushErrReported bool
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.w.Header()
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	// informational responses are sent immediately,
+	// as they are not covered by the signature.
+	if status >= 100 && status <= 199 && status != http.StatusSwitchingProtocols {
+		b.w.WriteHeader(status)
+		return
+	}
+
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.streaming {
+		return b.w.Write(p)
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// Flush signs and sends the buffered response, if the signature does
+// not cover the response body, and then flushes the underlying http.ResponseWriter.
+func (b *responseBuffer) Flush() {
+	if b.err != nil {
+		return
+	}
+
+	if !b.streaming {
+		if b.onFlush == nil || !b.onFlush() {
+			return
+		}
+		b.streaming = true
+	}
+
+	if f, ok := b.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// bodyAllowedForStatus reports whether a given response status code
+// permits a body. See RFC 7230, section 3.3.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,301 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/signature"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResponseSigner_SignResponse(t *testing.T) {
+	getCurrentTime = func() time.Time {
+		return time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	}
+
+	s := ResponseSigner{
+		KeyID: "testkey-123",
+		Tag:   "example-app",
+		Alg: testAlgorithm{
+			AlgType:   "ecdsa-p256-sha256",
+			Signature: "MOCK_SIGNATURE",
+		},
+		CoveredComponents: []string{"@status", "content-type", "content-digest"},
+		GetNonce: func() (string, error) {
+			return "MOCKNONCE", nil
+		},
+	}
+
+	res := &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Content-Type": {"application/json"},
+		},
+		Body: io.NopCloser(strings.NewReader(`{"hello": "world"}`)),
+	}
+
+	var gotStringToSign string
+	s.OnDeriveSigningString = func(_ context.Context, stringToSign string) {
+		gotStringToSign = stringToSign
+	}
+
+	got, err := s.SignResponse(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &signature.Message{
+		Input: sigparams.Params{
+			KeyID:             "testkey-123",
+			Tag:               "example-app",
+			Alg:               "ecdsa-p256-sha256",
+			CoveredComponents: []string{"@status", "content-type", "content-digest"},
+			Nonce:             "MOCKNONCE",
+			Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+		},
+		Signature: []byte("MOCK_SIGNATURE"),
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SignResponse() mismatch (-want +got):\n%s", diff)
+	}
+
+	wantStringToSign := `"@status": 200
+"content-type": application/json
+"content-digest": sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:
+"@signature-params": ("@status" "content-type" "content-digest");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";nonce="MOCKNONCE";created=1704254706`
+
+	if diff := cmp.Diff(wantStringToSign, gotStringToSign); diff != "" {
+		t.Errorf("string to sign mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResponseSigner_Handler(t *testing.T) {
+	getCurrentTime = func() time.Time {
+		return time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		alg        testAlgorithm
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+		wantSigned bool
+	}{
+		{
+			name: "ok",
+			alg:  testAlgorithm{AlgType: "ecdsa-p256-sha256", Signature: "MOCK_SIGNATURE"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("hello"))
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   "hello",
+			wantSigned: true,
+		},
+		{
+			name: "implicit_status",
+			alg:  testAlgorithm{AlgType: "ecdsa-p256-sha256", Signature: "MOCK_SIGNATURE"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("hello"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "hello",
+			wantSigned: true,
+		},
+		{
+			name: "signing_error",
+			alg:  testAlgorithm{AlgType: "ecdsa-p256-sha256", SignErr: errors.New("signing error")},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("hello"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := ResponseSigner{
+				KeyID:             "testkey-123",
+				Tag:               "example-app",
+				Alg:               tt.alg,
+				CoveredComponents: []string{"@status", "content-length", "content-digest"},
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "https://example.com", nil)
+
+			s.Handler(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %v, want %v", rec.Body.String(), tt.wantBody)
+			}
+
+			set, err := sigset.UnmarshalHeader(rec.Header())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantSigned {
+				msg, err := set.Find("example-app")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(msg.Signature) != "MOCK_SIGNATURE" {
+					t.Errorf("signature = %s, want MOCK_SIGNATURE", msg.Signature)
+				}
+				if rec.Header().Get("Content-Length") != "5" {
+					t.Errorf("content-length = %s, want 5", rec.Header().Get("Content-Length"))
+				}
+			} else if len(set.Messages) != 0 {
+				t.Errorf("expected response to be unsigned, got %v signatures", len(set.Messages))
+			}
+		})
+	}
+}
+
+func TestResponseSigner_Handler_Flush(t *testing.T) {
+	getCurrentTime = func() time.Time {
+		return time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	}
+
+	tests := []struct {
+		name              string
+		coveredComponents []string
+		contentLength     string
+		wantFlushed       bool
+		wantContentLength string
+		wantErr           error
+	}{
+		{
+			name:              "body_not_covered",
+			coveredComponents: []string{"@status", "content-type"},
+			wantFlushed:       true,
+		},
+		{
+			name:              "content_length_set_by_handler",
+			coveredComponents: []string{"@status", "content-length"},
+			contentLength:     "11",
+			wantFlushed:       true,
+			wantContentLength: "11",
+		},
+		{
+			name:              "content_digest_covered",
+			coveredComponents: []string{"@status", "content-digest"},
+			wantContentLength: "11",
+			wantErr:           ErrCannotFlush,
+		},
+		{
+			name:              "content_length_covered",
+			coveredComponents: []string{"@status", "content-length"},
+			wantContentLength: "11",
+			wantErr:           ErrCannotFlush,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotErr error
+
+			s := ResponseSigner{
+				KeyID:             "testkey-123",
+				Tag:               "example-app",
+				Alg:               testAlgorithm{AlgType: "ecdsa-p256-sha256", Signature: "MOCK_SIGNATURE"},
+				CoveredComponents: tt.coveredComponents,
+				OnSigningError: func(ctx context.Context, err error) {
+					gotErr = err
+				},
+			}
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				if tt.contentLength != "" {
+					w.Header().Set("Content-Length", tt.contentLength)
+				}
+				_, _ = w.Write([]byte("hello"))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte(" world"))
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "https://example.com", nil)
+
+			s.Handler(http.HandlerFunc(handler)).ServeHTTP(rec, req)
+
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Fatalf("signing error = %v, want %v", gotErr, tt.wantErr)
+			}
+
+			if rec.Flushed != tt.wantFlushed {
+				t.Errorf("flushed = %v, want %v", rec.Flushed, tt.wantFlushed)
+			}
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %v, want %v", rec.Code, http.StatusOK)
+			}
+
+			if rec.Body.String() != "hello world" {
+				t.Errorf("body = %v, want %v", rec.Body.String(), "hello world")
+			}
+
+			if got := rec.Header().Get("Content-Length"); got != tt.wantContentLength {
+				t.Errorf("content-length = %q, want %q", got, tt.wantContentLength)
+			}
+
+			set, err := sigset.UnmarshalHeader(rec.Header())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = set.Find("example-app")
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestResponseSigner_Handler_Head(t *testing.T) {
+	s := ResponseSigner{
+		KeyID:             "testkey-123",
+		Tag:               "example-app",
+		Alg:               testAlgorithm{AlgType: "ecdsa-p256-sha256", Signature: "MOCK_SIGNATURE"},
+		CoveredComponents: []string{"@status", "content-length"},
+	}
+
+	// the handler sets the length of the response to a GET request,
+	// without writing the body.
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "11")
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("HEAD", "https://example.com", nil)
+
+	s.Handler(http.HandlerFunc(handler)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	if got := rec.Header().Get("Content-Length"); got != "11" {
+		t.Errorf("content-length = %q, want %q", got, "11")
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %q, want empty body", rec.Body.String())
+	}
+}
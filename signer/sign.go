@@ -8,20 +8,45 @@ import (
 	"github.com/common-fate/httpsig/sigbase"
 	"github.com/common-fate/httpsig/signature"
 	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
 )
 
 // Sign a HTTP request following the process described in https://www.rfc-editor.org/rfc/rfc9421.html#section-3.1.
 //
 // This method will update the 'Signature-Input' and 'Signature' headers with a signature derived from the
 // signing algorithm specified with the 'Alg' field.
+//
+// If the 'content-length' component is covered and the length of the request body is unknown,
+// such as when streaming a body from an io.Pipe, the body is read into memory and
+// req.ContentLength is set, so that the request is not sent using chunked encoding.
+// The body is limited to MaxBodyBytes.
 func (t *Transport) Sign(req *http.Request) (*signature.Message, error) {
+	msg, _, err := t.sign(req)
+	return msg, err
+}
+
+// sign signs a HTTP request, returning the signature base along with the signature.
+func (t *Transport) sign(req *http.Request) (*signature.Message, *sigbase.Base, error) {
 	if t.Alg == nil {
-		return nil, errors.New("algorithm must not be nil")
+		return nil, nil, errors.New("algorithm must not be nil")
 	}
 
 	nonce, err := t.nonce()
 	if err != nil {
-		return nil, fmt.Errorf("generating nonce: %w", err)
+		return nil, nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	// the verifier must see the same content length as the signer.
+	if coversContentLength(t.CoveredComponents) && bodyLengthIsUnknown(req) {
+		err = measureBody(req, t.maxBodyBytes())
+		if err != nil {
+			return nil, nil, fmt.Errorf("measuring request body for content-length: %w", err)
+		}
+	}
+
+	covered, err := t.coveredComponents(req)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	params := sigparams.Params{
@@ -29,19 +54,19 @@ func (t *Transport) Sign(req *http.Request) (*signature.Message, error) {
 		Tag:               t.Tag,
 		Alg:               t.Alg.Type(),
 		Created:           getCurrentTime(),
-		CoveredComponents: t.CoveredComponents,
+		CoveredComponents: covered,
 		Nonce:             nonce,
 	}
 
 	// derive the signature base following the process in https://www.rfc-editor.org/rfc/rfc9421.html#create-sig-input
-	base, err := sigbase.Derive(params, nil, req, t.Alg.ContentDigest())
+	base, err := sigbase.Derive(params, nil, req, t.contentDigest())
 	if err != nil {
-		return nil, fmt.Errorf("deriving signature base: %w", err)
+		return nil, nil, fmt.Errorf("deriving signature base: %w", err)
 	}
 
 	stringToSign, err := base.CanonicalString(params)
 	if err != nil {
-		return nil, fmt.Errorf("creating string to sign: %w", err)
+		return nil, nil, fmt.Errorf("creating string to sign: %w", err)
 	}
 
 	if t.OnDeriveSigningString != nil {
@@ -51,7 +76,7 @@ func (t *Transport) Sign(req *http.Request) (*signature.Message, error) {
 	// sign the signature base according to the signing algorithm
 	sig, err := t.Alg.Sign(req.Context(), stringToSign)
 	if err != nil {
-		return nil, fmt.Errorf("error signing request: %w", err)
+		return nil, nil, fmt.Errorf("error signing request: %w", err)
 	}
 
 	// construct the HTTP message signature
@@ -60,5 +85,30 @@ func (t *Transport) Sign(req *http.Request) (*signature.Message, error) {
 		Signature: sig,
 	}
 
-	return &output, nil
+	return &output, base, nil
+}
+
+// coveredComponents returns the components to cover, including the
+// components for any existing signatures in CoveredSignatureTags.
+func (t *Transport) coveredComponents(req *http.Request) ([]string, error) {
+	if len(t.CoveredSignatureTags) == 0 {
+		return t.CoveredComponents, nil
+	}
+
+	set, err := sigset.Unmarshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	covered := append([]string{}, t.CoveredComponents...)
+
+	for _, tag := range t.CoveredSignatureTags {
+		label, _, err := set.FindWithLabel(tag)
+		if err != nil {
+			return nil, fmt.Errorf("finding signature to cover: %w", err)
+		}
+		covered = append(covered, sigparams.SignatureComponents(label)...)
+	}
+
+	return covered, nil
 }
@@ -1,7 +1,11 @@
 package signer
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -89,3 +93,62 @@ func TestSigner_Sign(t *testing.T) {
 		})
 	}
 }
+
+func TestSigner_Sign_UnknownContentLength(t *testing.T) {
+	s := Transport{
+		Alg:               testAlgorithm{AlgType: "ecdsa-p256-sha256", Signature: "MOCK_SIGNATURE"},
+		CoveredComponents: []string{"@method", "content-length"},
+	}
+
+	var got string
+	s.OnDeriveSigningString = func(ctx context.Context, stringToSign string) {
+		got = stringToSign
+	}
+
+	// a reader without a known length results in a ContentLength of 0.
+	req, err := http.NewRequest("POST", "https://example.com", io.MultiReader(strings.NewReader("hello")))
+	if err != nil {
+		t.Fatalf("error constructing test HTTP request: %s", err)
+	}
+
+	_, err = s.Sign(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.ContentLength != 5 {
+		t.Fatalf("ContentLength = %d, want 5", req.ContentLength)
+	}
+
+	if !strings.Contains(got, `"content-length": 5`) {
+		t.Fatalf("string to sign did not contain the measured content length:\n%s", got)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hello" {
+		t.Fatalf("body = %q, want hello", body)
+	}
+}
+
+func TestSigner_Sign_UnknownContentLength_TooLarge(t *testing.T) {
+	s := Transport{
+		Alg:               testAlgorithm{AlgType: "ecdsa-p256-sha256", Signature: "MOCK_SIGNATURE"},
+		CoveredComponents: []string{"@method", "content-length"},
+		MaxBodyBytes:      4,
+	}
+
+	req, err := http.NewRequest("POST", "https://example.com", io.MultiReader(strings.NewReader("hello")))
+	if err != nil {
+		t.Fatalf("error constructing test HTTP request: %s", err)
+	}
+
+	_, err = s.Sign(req)
+
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		t.Fatalf("expected a *http.MaxBytesError, got %v", err)
+	}
+}
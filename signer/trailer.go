@@ -0,0 +1,165 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+)
+
+// coversTrailers returns true if any of the covered components
+// have the 'tr' parameter.
+func (t *Transport) coversTrailers() bool {
+	for _, cc := range t.CoveredComponents {
+		c, err := sigparams.ParseComponent(cc)
+		if err != nil {
+			continue
+		}
+		if c.HasParam("tr") {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareTrailerRequest prepares a request to be signed using trailers.
+//
+// Trailer fields are not known until the request body has been sent, so
+// the signature is sent in the Signature and Signature-Input trailers.
+// The request body is streamed rather than being read into memory:
+// if 'content-digest;tr' is covered, the digest is calculated as the
+// body is sent and is included as a Content-Digest trailer.
+//
+// The request is signed once the body has been read to EOF,
+// and the label and parameters of the signature are stored in sent.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.4
+func (t *Transport) prepareTrailerRequest(req2 *http.Request, set *sigset.Set, sent *sentSignature) error {
+	if t.Alg == nil {
+		return errors.New("algorithm must not be nil")
+	}
+
+	if req2.Body == nil || req2.Body == http.NoBody {
+		return errors.New("covering trailer fields requires a request body")
+	}
+
+	var coversDigest bool
+
+	for _, cc := range t.CoveredComponents {
+		c, err := sigparams.ParseComponent(cc)
+		if err != nil {
+			return err
+		}
+
+		if c.HasParam("tr") {
+			if c.Name == "content-digest" {
+				coversDigest = true
+			}
+			continue
+		}
+
+		// the request body is streamed, so the content length and digest
+		// are not known when the request headers are sent.
+		if c.Name == "content-digest" || c.Name == "content-length" {
+			return fmt.Errorf("%q cannot be covered when signing trailer fields: use 'content-digest;tr' to cover the request body", cc)
+		}
+	}
+
+	// declare the trailer fields, so that the request is sent
+	// with chunked transfer encoding.
+	trailer := make(http.Header, len(req2.Trailer)+3)
+	for k, s := range req2.Trailer {
+		trailer[k] = append([]string(nil), s...)
+	}
+	trailer["Signature"] = nil
+	trailer["Signature-Input"] = nil
+	if coversDigest {
+		trailer["Content-Digest"] = nil
+	}
+
+	req2.Trailer = trailer
+	req2.ContentLength = -1
+
+	digester := t.contentDigest()
+
+	body := &trailerBody{
+		body: req2.Body,
+		hash: digester.HashFunc(),
+	}
+
+	body.onEOF = func() error {
+		if coversDigest {
+			digest, err := digester.Format(body.hash.Sum(nil))
+			if err != nil {
+				return fmt.Errorf("formatting content digest: %w", err)
+			}
+			trailer.Set("Content-Digest", digest)
+		}
+
+		// the body has been sent, so the signature base
+		// is derived from the request headers and trailers only.
+		signReq := cloneRequest(req2)
+		signReq.Body = http.NoBody
+
+		ms, err := t.Sign(signReq)
+		if err != nil {
+			return err
+		}
+
+		// choose a label after the existing signatures,
+		// so that the label is unique within the message.
+		label, err := t.addToSet(set, ms)
+		if err != nil {
+			return err
+		}
+		*sent = sentSignature{label: label, params: ms.Input}
+
+		var trailerSet sigset.Set
+		err = trailerSet.AddWithLabel(label, ms)
+		if err != nil {
+			return err
+		}
+
+		err = trailerSet.IncludeHeader(trailer)
+		if err != nil {
+			return fmt.Errorf("including signature in HTTP trailer: %w", err)
+		}
+
+		return nil
+	}
+
+	req2.Body = body
+
+	return nil
+}
+
+// trailerBody is a request body which hashes the body
+// as it is read, calling onEOF when the body has been read.
+type trailerBody struct {
+	body  io.ReadCloser
+	hash  hash.Hash
+	onEOF func() error
+	done  bool
+}
+
+func (b *trailerBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.hash.Write(p[:n])
+
+	if err == io.EOF && !b.done {
+		b.done = true
+		if signErr := b.onEOF(); signErr != nil {
+			return n, fmt.Errorf("signing HTTP trailers: %w", signErr)
+		}
+	}
+
+	return n, err
+}
+
+func (b *trailerBody) Close() error {
+	return b.body.Close()
+}
@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/common-fate/httpsig/contentdigest"
 	"github.com/common-fate/httpsig/sigset"
+	"github.com/common-fate/httpsig/verifier"
 )
 
 // getCurrentTime allows the current time to be overridden for testing.
@@ -41,8 +43,28 @@ type Transport struct {
 	// to facilitate reconstruction of the signature base.
 	//
 	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-1.1-7.18.1
+	//
+	// If any components have the 'tr' parameter, such as 'content-digest;tr',
+	// the request body is streamed rather than being read into memory, and the
+	// signature is sent in the Signature and Signature-Input trailers.
+	//
+	// If 'content-digest' or 'repr-digest' is covered, the Content-Digest or
+	// Repr-Digest header is added to the request, so that the covered field is
+	// present in the message. The headers are not added if they are not covered.
 	CoveredComponents []string
 
+	// CoveredSignatureTags, if set, are the tags of existing signatures on the
+	// request to cover, in addition to CoveredComponents. This allows an
+	// intermediary such as a proxy to attest to a signature it has verified.
+	//
+	// Each signature is covered using the 'signature' and 'signature-input'
+	// components with the 'key' parameter set to the label of the signature.
+	// Signing fails if the request does not have exactly one signature
+	// matching each tag.
+	//
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-4.3
+	CoveredSignatureTags []string
+
 	// GetNonce can optionally be provided to override the built-in
 	// nonce generation function. If the provided Nonce function
 	// returns an empty string, a nonce will not be included
@@ -57,6 +79,15 @@ type Transport struct {
 	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.3-4.6
 	GetNonce func() (string, error)
 
+	// MaxBodyBytes, if non-zero, is the maximum number of bytes of a request
+	// body of unknown length to read into memory when the 'content-length'
+	// component is covered. Signing fails with a *http.MaxBytesError if the
+	// body is larger.
+	//
+	// If zero, the MaxBytes of the content digester is used,
+	// which is 10MB by default.
+	MaxBodyBytes int64
+
 	// BaseTransport is the underlying HTTP transport to use
 	// for sending requests after they have been signed.
 	//
@@ -70,6 +101,46 @@ type Transport struct {
 	// as you can compare the base signing string between the client
 	// and server.
 	OnDeriveSigningString func(ctx context.Context, stringToSign string)
+
+	// ResponseVerifier, if set, is used to verify the signature
+	// on HTTP responses before they are returned.
+	//
+	// If the response signature cannot be verified, RoundTrip
+	// returns an error. Response headers which are not covered
+	// by the signature are removed, and the response body is replaced
+	// with verifier.UncoveredBody if it is not covered by the signature.
+	ResponseVerifier *verifier.Verifier
+
+	// RetryOnAcceptSignature, if true, retries requests which are rejected
+	// with a 401 Unauthorized response containing an Accept-Signature field.
+	// The request is re-signed to cover the components requested by the server,
+	// using the label requested by the server.
+	//
+	// The request is retried once, and only if the server requested components
+	// which were not covered by the signature. Requests with a body are only
+	// retried if req.GetBody is set.
+	//
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-5.1
+	RetryOnAcceptSignature bool
+
+	// ContentDigestNegotiator, if set, selects the digest algorithm used for
+	// the 'content-digest' and 'repr-digest' components based on the
+	// Want-Content-Digest and Want-Repr-Digest fields in responses.
+	//
+	// The strongest algorithm supported by both the client and server is used
+	// for subsequent requests. Until an algorithm has been negotiated, the
+	// algorithm from Alg.ContentDigest() is used. Algorithms weaker than the
+	// algorithm from Alg.ContentDigest() are not used, as the server rejects
+	// digests which are weaker than the digest algorithm of the key.
+	//
+	// See: https://www.rfc-editor.org/rfc/rfc9530.html#section-4
+	ContentDigestNegotiator *contentdigest.Negotiator
+
+	// Labeler, if set, chooses the label for the signature. The label
+	// must not already be in use by an existing signature on the request.
+	//
+	// If nil, labels such as 'sig1', 'sig2' etc are used.
+	Labeler Labeler
 }
 
 // RoundTrip implements the http.RoundTripper interface.
@@ -92,29 +163,92 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
-	// derive the signature.
-	ms, err := t.Sign(req)
+	var (
+		req2 *http.Request
+		sent sentSignature
+	)
+
+	if t.coversTrailers() {
+		// as per the http.RoundTripper contract, roundtrippers
+		// may not modify the request.
+		req2 = cloneRequest(req)
+
+		// the request is signed after the body has been sent,
+		// and the signature is included in the trailers.
+		err = t.prepareTrailerRequest(req2, set, &sent)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// as per the http.RoundTripper contract, roundtrippers
+		// may not modify the request, so the clone is signed,
+		// as signing may replace the request body.
+		req2 = cloneRequest(req)
+
+		// derive the signature.
+		ms, base, err := t.sign(req2)
+		if err != nil {
+			return nil, err
+		}
+
+		// a covered field must be present in the message, so the digest
+		// of the body is sent if it is covered. This also allows the server
+		// to verify the signature before reading the body.
+		// Digests are not sent for components which are not covered.
+		if digest, ok := base.Values["content-digest"]; ok && digest != "" {
+			req2.Header.Set("Content-Digest", digest)
+		}
+		if digest, ok := base.Values["repr-digest"]; ok && digest != "" && req2.Header.Get("Repr-Digest") == "" {
+			req2.Header.Set("Repr-Digest", digest)
+		}
+
+		// add the signature to the set, after any existing signatures.
+		label, err := t.addToSet(set, ms)
+		if err != nil {
+			return nil, err
+		}
+		sent = sentSignature{label: label, params: ms.Input}
+
+		// include the signature in the cloned HTTP request.
+		err = set.Include(req2)
+		if err != nil {
+			return nil, fmt.Errorf("including signature in HTTP request: %w", err)
+		}
+	}
+
+	// req.Body is assumed to be closed by the base RoundTripper.
+	reqBodyClosed = true
+
+	res, err := t.base().RoundTrip(req2)
 	if err != nil {
 		return nil, err
 	}
 
-	// as per the http.RoundTripper contract, roundtrippers
-	// may not modify the request.
-	req2 := cloneRequest(req)
+	if t.ContentDigestNegotiator != nil {
+		// the preferences only apply to subsequent requests, so
+		// invalid preferences do not cause this request to fail.
+		_ = t.ContentDigestNegotiator.Update(res.Header)
+	}
 
-	// add the signature to the set
-	set.Add(ms)
+	if t.RetryOnAcceptSignature && res.StatusCode == http.StatusUnauthorized {
+		retry, retryReq, ok := t.acceptSignatureRetry(req, res, &sent)
+		if ok {
+			discardBody(res)
+			return retry.RoundTrip(retryReq)
+		}
+	}
 
-	// include the signature in the cloned HTTP request.
-	err = set.Include(req2)
-	if err != nil {
-		return nil, fmt.Errorf("including signature in HTTP request: %w", err)
+	if t.ResponseVerifier == nil {
+		return res, nil
 	}
 
-	// req.Body is assumed to be closed by the base RoundTripper.
-	reqBodyClosed = true
+	parsed, _, err := t.ResponseVerifier.ParseResponse(res, getCurrentTime())
+	if err != nil {
+		res.Body.Close()
+		return nil, fmt.Errorf("verifying response signature: %w", err)
+	}
 
-	return t.base().RoundTrip(req2)
+	return parsed, nil
 }
 
 func (t *Transport) base() http.RoundTripper {
@@ -137,3 +271,12 @@ func cloneRequest(r *http.Request) *http.Request {
 	}
 	return r2
 }
+
+// contentDigest returns the digester used to hash request bodies.
+func (t *Transport) contentDigest() contentdigest.Digester {
+	d := t.Alg.ContentDigest()
+	if t.ContentDigestNegotiator != nil {
+		d = t.ContentDigestNegotiator.Digester(d)
+	}
+	return d
+}
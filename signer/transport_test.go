@@ -0,0 +1,75 @@
+package signer
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// captureTransport records the request which was sent.
+type captureTransport struct {
+	req *http.Request
+}
+
+func (c *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.req = req
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestTransport_RoundTrip_DigestHeaders(t *testing.T) {
+	tests := []struct {
+		name              string
+		coveredComponents []string
+		wantHeaders       []string
+	}{
+		{
+			name:              "content_digest_covered",
+			coveredComponents: []string{"@method", "content-digest"},
+			wantHeaders:       []string{"Content-Digest"},
+		},
+		{
+			name:              "repr_digest_covered",
+			coveredComponents: []string{"@method", "repr-digest"},
+			wantHeaders:       []string{"Repr-Digest"},
+		},
+		{
+			name:              "not_covered",
+			coveredComponents: []string{"@method", "content-length"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &captureTransport{}
+
+			tr := &Transport{
+				Tag:               "example-app",
+				Alg:               testAlgorithm{AlgType: "ecdsa-p256-sha256", Signature: "MOCK_SIGNATURE"},
+				CoveredComponents: tt.coveredComponents,
+				BaseTransport:     base,
+			}
+
+			req, err := http.NewRequest("POST", "https://example.com", strings.NewReader(`{"hello": "world"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = tr.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, h := range []string{"Content-Digest", "Repr-Digest"} {
+				if base.req.Header.Get(h) != "" {
+					got = append(got, h)
+				}
+			}
+
+			if diff := cmp.Diff(tt.wantHeaders, got); diff != "" {
+				t.Errorf("digest headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
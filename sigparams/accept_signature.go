@@ -0,0 +1,145 @@
+package sigparams
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/dunglas/httpsfv"
+)
+
+// AcceptSignature describes a HTTP message signature which is
+// requested by the recipient of a message, as sent in the
+// Accept-Signature field.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-5.1
+type AcceptSignature struct {
+	// CoveredComponents are the components which
+	// the signature is requested to cover.
+	CoveredComponents []string
+
+	// KeyID, if set, is the identifier of the key which
+	// the signature is requested to use.
+	KeyID string
+
+	// Alg, if set, is the signing algorithm which
+	// the signature is requested to use.
+	Alg string
+
+	// Tag, if set, is the tag which the signature is requested to use.
+	Tag string
+
+	// Created, if true, requests that the signature includes
+	// the 'created' parameter.
+	Created bool
+
+	// Nonce, if true, requests that the signature includes
+	// the 'nonce' parameter.
+	Nonce bool
+}
+
+// SFV converts the requested signature to a HTTP structured field value.
+//
+// Parameters which are generated by the signer, such as 'created' and 'nonce',
+// are serialized as boolean parameters.
+func (a AcceptSignature) SFV() *httpsfv.InnerList {
+	list := httpsfv.InnerList{
+		Items:  make([]httpsfv.Item, len(a.CoveredComponents)),
+		Params: httpsfv.NewParams(),
+	}
+
+	for i, cc := range a.CoveredComponents {
+		c, err := ParseComponent(cc)
+		if err != nil {
+			// fall back to treating the identifier as a component name.
+			list.Items[i] = httpsfv.NewItem(cc)
+			continue
+		}
+		list.Items[i] = c.Item()
+	}
+
+	if a.KeyID != "" {
+		list.Params.Add("keyid", a.KeyID)
+	}
+
+	if a.Alg != "" {
+		list.Params.Add("alg", a.Alg)
+	}
+
+	if a.Tag != "" {
+		list.Params.Add("tag", a.Tag)
+	}
+
+	if a.Nonce {
+		list.Params.Add("nonce", true)
+	}
+
+	if a.Created {
+		list.Params.Add("created", true)
+	}
+
+	return &list
+}
+
+// UnmarshalAcceptInnerList parses a requested signature
+// from a member of the Accept-Signature field.
+func UnmarshalAcceptInnerList(input httpsfv.InnerList) (*AcceptSignature, error) {
+	var a AcceptSignature
+	var err error
+
+	if len(input.Items) > 0 {
+		a.CoveredComponents = make([]string, len(input.Items))
+
+		for i, item := range input.Items {
+			str, ok := item.Value.(string)
+			if !ok {
+				return nil, errors.New("could not cast covered component item to string")
+			}
+			c := Component{Name: str, Params: item.Params}
+			a.CoveredComponents[i] = c.String()
+		}
+	}
+
+	a.Alg, err = getOptionalString(input.Params, "alg")
+	if err != nil {
+		return nil, err
+	}
+
+	a.KeyID, err = getOptionalString(input.Params, "keyid")
+	if err != nil {
+		return nil, err
+	}
+
+	a.Tag, err = getOptionalString(input.Params, "tag")
+	if err != nil {
+		return nil, err
+	}
+
+	// the requester may send 'created' and 'nonce' with any value,
+	// as the values are generated by the signer.
+	_, a.Created = input.Params.Get("created")
+	_, a.Nonce = input.Params.Get("nonce")
+
+	return &a, nil
+}
+
+// AcceptSignature returns the signature requested by the validation options,
+// so that it can be sent to a signer in the Accept-Signature field.
+//
+// The required covered components are sorted, as their order is not significant.
+func (opts ValidateOpts) AcceptSignature(tag string) AcceptSignature {
+	a := AcceptSignature{
+		Tag:     tag,
+		Created: true,
+		Nonce:   opts.RequireNonce,
+	}
+
+	for cc, required := range opts.RequiredCoveredComponents {
+		if required {
+			a.CoveredComponents = append(a.CoveredComponents, cc)
+		}
+	}
+
+	sort.Strings(a.CoveredComponents)
+
+	return a
+}
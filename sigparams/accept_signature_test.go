@@ -0,0 +1,32 @@
+package sigparams
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateOpts_AcceptSignature(t *testing.T) {
+	opts := ValidateOpts{
+		RequiredCoveredComponents: map[string]bool{
+			"@target-uri":    true,
+			"@method":        true,
+			"content-digest": true,
+			"content-type":   false,
+		},
+		RequireNonce: true,
+	}
+
+	got := opts.AcceptSignature("app-123")
+
+	want := AcceptSignature{
+		CoveredComponents: []string{"@method", "@target-uri", "content-digest"},
+		Tag:               "app-123",
+		Created:           true,
+		Nonce:             true,
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AcceptSignature() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,114 @@
+package sigparams
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dunglas/httpsfv"
+)
+
+// Component is a HTTP message component identifier,
+// made up of a component name and optional parameters.
+//
+// In CoveredComponents, component identifiers are represented as
+// the component name followed by any serialized parameters,
+// such as '@method;req'. A component without parameters is
+// represented by its name alone, such as 'content-type'.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2
+type Component struct {
+	Name   string
+	Params *httpsfv.Params
+}
+
+// ParseComponent parses a component identifier in the form
+// used by CoveredComponents.
+func ParseComponent(identifier string) (Component, error) {
+	if identifier == "" {
+		return Component{}, errors.New("identifier was empty")
+	}
+
+	name, params, hasParams := strings.Cut(identifier, ";")
+	if name == "" {
+		return Component{}, fmt.Errorf("identifier %q had an empty component name", identifier)
+	}
+
+	if !hasParams {
+		return Component{Name: name, Params: httpsfv.NewParams()}, nil
+	}
+
+	// parse the parameters by serializing the component
+	// identifier as a structured field item.
+	quotedName, err := httpsfv.Marshal(httpsfv.NewItem(name))
+	if err != nil {
+		return Component{}, fmt.Errorf("invalid component name %q: %w", name, err)
+	}
+
+	item, err := httpsfv.UnmarshalItem([]string{quotedName + ";" + params})
+	if err != nil {
+		return Component{}, fmt.Errorf("invalid parameters for component identifier %q: %w", identifier, err)
+	}
+
+	return Component{Name: name, Params: item.Params}, nil
+}
+
+// HasParam returns true if the component has the parameter.
+func (c Component) HasParam(key string) bool {
+	if c.Params == nil {
+		return false
+	}
+	_, ok := c.Params.Get(key)
+	return ok
+}
+
+// Item returns the component identifier as a structured field item,
+// as it is serialized in the Signature-Input field and the signature base.
+func (c Component) Item() httpsfv.Item {
+	item := httpsfv.NewItem(c.Name)
+	if c.Params != nil {
+		item.Params = c.Params
+	}
+	return item
+}
+
+// String returns the component identifier in the form used by
+// CoveredComponents.
+func (c Component) String() string {
+	if c.Params == nil || len(c.Params.Names()) == 0 {
+		return c.Name
+	}
+
+	serialized, err := httpsfv.Marshal(c.Item())
+	if err != nil {
+		return c.Name
+	}
+
+	quotedName, err := httpsfv.Marshal(httpsfv.NewItem(c.Name))
+	if err != nil {
+		return c.Name
+	}
+
+	return c.Name + strings.TrimPrefix(serialized, quotedName)
+}
+
+// SignatureComponents returns the component identifiers which cover an
+// existing signature in the message with the given label, by covering the
+// members of the Signature and Signature-Input fields.
+//
+// This allows an intermediary such as a proxy to attest to a signature
+// which it has verified.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-4.3
+func SignatureComponents(label string) []string {
+	fields := []string{"signature", "signature-input"}
+
+	identifiers := make([]string, len(fields))
+	for i, name := range fields {
+		params := httpsfv.NewParams()
+		params.Add("key", label)
+		identifiers[i] = Component{Name: name, Params: params}.String()
+	}
+
+	return identifiers
+}
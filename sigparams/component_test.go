@@ -0,0 +1,132 @@
+package sigparams
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseComponent(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		wantName   string
+		wantParams []string
+		wantErr    bool
+	}{
+		{
+			name:       "name_only",
+			identifier: "content-type",
+			wantName:   "content-type",
+		},
+		{
+			name:       "derived_component",
+			identifier: "@method",
+			wantName:   "@method",
+		},
+		{
+			name:       "boolean_param",
+			identifier: "@method;req",
+			wantName:   "@method",
+			wantParams: []string{"req"},
+		},
+		{
+			name:       "multiple_params",
+			identifier: `signature;req;key="sig1"`,
+			wantName:   "signature",
+			wantParams: []string{"req", "key"},
+		},
+		{
+			name:       "empty",
+			identifier: "",
+			wantErr:    true,
+		},
+		{
+			name:       "empty_name",
+			identifier: ";req",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid_params",
+			identifier: "@method;;",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseComponent(tt.identifier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseComponent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Name != tt.wantName {
+				t.Errorf("Name = %v, want %v", got.Name, tt.wantName)
+			}
+
+			for _, param := range tt.wantParams {
+				if !got.HasParam(param) {
+					t.Errorf("expected param %q to be present", param)
+				}
+			}
+
+			// the identifier should roundtrip.
+			if got.String() != tt.identifier {
+				t.Errorf("String() = %v, want %v", got.String(), tt.identifier)
+			}
+		})
+	}
+}
+
+func TestSignatureComponents(t *testing.T) {
+	got := SignatureComponents("sig1")
+	want := []string{`signature;key="sig1"`, `signature-input;key="sig1"`}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SignatureComponents() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParams_CoversSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		covered []string
+		want    bool
+	}{
+		{
+			name:    "ok",
+			covered: []string{"@method", `signature;key="sig1"`, `signature-input;key="sig1"`},
+			want:    true,
+		},
+		{
+			name:    "missing_signature_input",
+			covered: []string{`signature;key="sig1"`},
+			want:    false,
+		},
+		{
+			name:    "different_label",
+			covered: []string{`signature;key="sig2"`, `signature-input;key="sig2"`},
+			want:    false,
+		},
+		{
+			name:    "trailer",
+			covered: []string{`signature;key="sig1";tr`, `signature-input;key="sig1";tr`},
+			want:    false,
+		},
+		{
+			name:    "whole_field",
+			covered: []string{"signature", "signature-input"},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Params{CoveredComponents: tt.covered}
+			if got := p.CoversSignature("sig1"); got != tt.want {
+				t.Errorf("Params.CoversSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
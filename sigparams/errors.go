@@ -0,0 +1,39 @@
+package sigparams
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSignatureExpired is returned by Validate if the signature
+	// was created too long ago, or its expiry time has passed.
+	ErrSignatureExpired = errors.New("signature has expired")
+
+	// ErrSignatureNotYetValid is returned by Validate if the signature
+	// was created in the future.
+	ErrSignatureNotYetValid = errors.New("signature is not yet valid")
+
+	// ErrInvalidTimestamps is returned by Validate if the 'expires'
+	// parameter is earlier than the 'created' parameter.
+	ErrInvalidTimestamps = errors.New("signature expires before it was created")
+
+	// ErrClientSideAlgForbidden is returned by Validate if the
+	// 'alg' parameter was provided but is forbidden by the validation options.
+	ErrClientSideAlgForbidden = errors.New("client side alg specification is forbidden")
+
+	// ErrNonceRequired is returned by Validate if the 'nonce'
+	// parameter is required but was not provided.
+	ErrNonceRequired = errors.New("nonce is required")
+)
+
+// MissingComponentError is returned by Validate if a
+// required covered component was not included in the signature.
+type MissingComponentError struct {
+	// Component is the identifier of the missing component.
+	Component string
+}
+
+func (e *MissingComponentError) Error() string {
+	return fmt.Sprintf("required covered component %q was not present", e.Component)
+}
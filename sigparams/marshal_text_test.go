@@ -44,6 +44,23 @@ func TestSigner_marshalText(t *testing.T) {
 			},
 			want: `("@method" "@target-uri");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";created=1704254706`,
 		},
+		{
+			name: "with_component_params",
+			fields: Params{
+				CoveredComponents: []string{"@status", "@method;req"},
+				KeyID:             "testkey-123",
+				Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			},
+			want: `("@status" "@method";req);keyid="testkey-123";created=1704254706`,
+		},
+		{
+			name: "with_structured_field_params",
+			fields: Params{
+				CoveredComponents: []string{"cache-control;sf", `priority;key="u"`},
+				Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			},
+			want: `("cache-control";sf "priority";key="u");created=1704254706`,
+		},
 		{
 			name: "with_nonce",
 			fields: Params{
@@ -38,3 +38,25 @@ type Params struct {
 
 	Expires time.Time
 }
+
+// CoversSignature returns true if the Signature and Signature-Input
+// members for the signature with the given label are covered.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-4.3
+func (p Params) CoversSignature(label string) bool {
+	covered := map[string]bool{}
+
+	for _, cc := range p.CoveredComponents {
+		c, err := ParseComponent(cc)
+		if err != nil || c.HasParam("tr") {
+			continue
+		}
+
+		key, _ := c.Params.Get("key")
+		if key == label {
+			covered[c.Name] = true
+		}
+	}
+
+	return covered["signature"] && covered["signature-input"]
+}
@@ -12,7 +12,13 @@ func (p Params) SFV() *httpsfv.InnerList {
 	}
 
 	for i, cc := range p.CoveredComponents {
-		sigParams.Items[i] = httpsfv.NewItem(cc)
+		c, err := ParseComponent(cc)
+		if err != nil {
+			// fall back to treating the identifier as a component name.
+			sigParams.Items[i] = httpsfv.NewItem(cc)
+			continue
+		}
+		sigParams.Items[i] = c.Item()
 	}
 
 	if p.KeyID != "" {
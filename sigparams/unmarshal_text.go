@@ -20,7 +20,8 @@ func UnmarshalInnerList(input httpsfv.InnerList) (*Params, error) {
 			if !ok {
 				return nil, errors.New("could not cast covered component item to string")
 			}
-			p.CoveredComponents[i] = str
+			c := Component{Name: str, Params: item.Params}
+			p.CoveredComponents[i] = c.String()
 		}
 	}
 
@@ -27,6 +27,15 @@ func TestParams_UnmarshalInnerList(t *testing.T) {
 				Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.FixedZone("GMT", 0)),
 			},
 		},
+		{
+			name:  "component_params",
+			input: `("@status" "@method";req "@target-uri";req);keyid="testkey-123";created=1704254706`,
+			want: Params{
+				KeyID:             "testkey-123",
+				CoveredComponents: []string{"@status", "@method;req", "@target-uri;req"},
+				Created:           time.Date(2024, 01, 03, 04, 05, 06, 00, time.FixedZone("GMT", 0)),
+			},
+		},
 		{
 			name:  "empty covered components",
 			input: `();keyid="testkey-123";alg="ecdsa-p256-sha256";tag="foo";created=1704254706`,
@@ -1,7 +1,6 @@
 package sigparams
 
 import (
-	"errors"
 	"fmt"
 	"time"
 )
@@ -30,31 +29,31 @@ type ValidateOpts struct {
 
 func (p Params) Validate(opts ValidateOpts, now time.Time) error {
 	if opts.ForbidClientSideAlg && p.Alg != "" {
-		return fmt.Errorf("client side alg specification is forbidden but alg %q was provided", p.Alg)
+		return fmt.Errorf("%w but alg %q was provided", ErrClientSideAlgForbidden, p.Alg)
 	}
 
 	if !p.Expires.IsZero() && p.Expires.Before(p.Created) {
-		return fmt.Errorf("expires timestamp %s was before created timestamp %s", p.Expires, p.Created)
+		return fmt.Errorf("%w: expires timestamp %s was before created timestamp %s", ErrInvalidTimestamps, p.Expires, p.Created)
 	}
 
 	notBefore := now.Add(-opts.BeforeDuration)
 
 	if p.Created.Before(notBefore) {
-		return fmt.Errorf("created timestamp %s was earlier than earliest allowed value %s", p.Created, notBefore)
+		return fmt.Errorf("%w: created timestamp %s was earlier than earliest allowed value %s", ErrSignatureExpired, p.Created, notBefore)
 	}
 
 	notAfter := now.Add(opts.AfterDuration)
 
 	if p.Created.After(notAfter) {
-		return fmt.Errorf("created timestamp %s was after latest allowed value %s", p.Created, notAfter)
+		return fmt.Errorf("%w: created timestamp %s was after latest allowed value %s", ErrSignatureNotYetValid, p.Created, notAfter)
 	}
 
 	if !p.Expires.IsZero() && p.Expires.Before(notAfter) {
-		return fmt.Errorf("expires timestamp %s was before latest allowed value %s", p.Expires, notAfter)
+		return fmt.Errorf("%w: expires timestamp %s was before latest allowed value %s", ErrSignatureExpired, p.Expires, notAfter)
 	}
 
 	if opts.RequireNonce && p.Nonce == "" {
-		return errors.New("nonce is required")
+		return ErrNonceRequired
 	}
 
 	allComponents := map[string]bool{}
@@ -65,7 +64,7 @@ func (p Params) Validate(opts ValidateOpts, now time.Time) error {
 
 	for required := range opts.RequiredCoveredComponents {
 		if !allComponents[required] {
-			return fmt.Errorf("required covered component %q was not present", required)
+			return &MissingComponentError{Component: required}
 		}
 	}
 
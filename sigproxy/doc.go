@@ -0,0 +1,17 @@
+/*
+Package sigproxy provides a reverse proxy which verifies HTTP message
+signatures on incoming requests, and re-signs the requests it forwards
+to an upstream service using its own key.
+
+This allows a signature-enforcing gateway to be placed in front of
+services which do not implement RFC 9421: the upstream service only
+needs to trust the gateway, and unsigned headers and request bodies are
+never forwarded.
+
+Optionally, the Signature and Signature-Input fields of the incoming
+request can be forwarded, so that the gateway's signature can attest to
+the client signature it has verified.
+
+See: https://www.rfc-editor.org/rfc/rfc9421.html#section-4.3
+*/
+package sigproxy
@@ -0,0 +1,204 @@
+package sigproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// Opts configures a Proxy.
+type Opts struct {
+	// Target is the URL of the upstream service.
+	//
+	// The path of incoming requests is joined to the path of Target.
+	Target *url.URL
+
+	// Verifier verifies the signatures on incoming requests.
+	//
+	// Only the headers, trailers and request body covered by
+	// the verified signature are forwarded.
+	//
+	// The Verifier must require the '@method' and '@target-uri' components
+	// to be covered. Otherwise, the proxy would re-sign a request with a
+	// method or path which the client did not sign.
+	Verifier *verifier.Verifier
+
+	// Signer signs the requests forwarded to the upstream service.
+	//
+	// Signer.BaseTransport is used to send the requests.
+	Signer *signer.Transport
+
+	// KeepSignatures, if true, forwards the Signature and Signature-Input
+	// fields of the incoming request. The proxy's signature is added
+	// after the existing signatures.
+	//
+	// To attest to the verified signature, set Signer.CoveredSignatureTags
+	// to include Verifier.Tag. Note that the incoming signature was created
+	// for the proxy's authority, so the upstream service can't verify it directly.
+	KeepSignatures bool
+
+	// OnValidationError, if set, is called with the error
+	// if the signature on an incoming request could not be verified.
+	OnValidationError func(ctx context.Context, err error)
+
+	// OnFailure is called to write the response if the signature
+	// on an incoming request could not be verified.
+	//
+	// If nil, httpsig.WriteProblem is used.
+	OnFailure func(w http.ResponseWriter, r *http.Request, err error)
+
+	// ErrorHandler is called if the request could not be signed
+	// or forwarded to the upstream service.
+	//
+	// If nil, a 502 Bad Gateway response is written.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Proxy is a reverse proxy which verifies incoming requests
+// and re-signs them before forwarding them upstream.
+type Proxy struct {
+	verifier          *verifier.Verifier
+	keepSignatures    bool
+	onValidationError func(ctx context.Context, err error)
+	onFailure         func(w http.ResponseWriter, r *http.Request, err error)
+
+	rp *httputil.ReverseProxy
+}
+
+var _ http.Handler = &Proxy{}
+
+// New creates a Proxy which forwards verified requests to opts.Target.
+func New(opts Opts) (*Proxy, error) {
+	if opts.Target == nil {
+		return nil, errors.New("sigproxy: Target must be provided")
+	}
+	if opts.Verifier == nil {
+		return nil, errors.New("sigproxy: Verifier must be provided")
+	}
+	if opts.Signer == nil {
+		return nil, errors.New("sigproxy: Signer must be provided")
+	}
+	err := requireRequestTarget(opts.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	onFailure := opts.OnFailure
+	if onFailure == nil {
+		onFailure = httpsig.WriteProblem
+	}
+
+	target := opts.Target
+	keepSignatures := opts.KeepSignatures
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+
+			// SetURL clears the Host, but the signer requires
+			// it to derive the @authority component.
+			pr.Out.Host = pr.Out.URL.Host
+
+			// signatures are only forwarded if they were asked for, in case
+			// the client covered the Signature fields in its own signature.
+			if !keepSignatures {
+				pr.Out.Header.Del("Signature")
+				pr.Out.Header.Del("Signature-Input")
+			}
+		},
+		Transport:    opts.Signer,
+		ErrorHandler: opts.ErrorHandler,
+	}
+
+	p := &Proxy{
+		verifier:          opts.Verifier,
+		keepSignatures:    opts.KeepSignatures,
+		onValidationError: opts.OnValidationError,
+		onFailure:         onFailure,
+		rp:                rp,
+	}
+
+	return p, nil
+}
+
+// ServeHTTP verifies the signature on the request and forwards it upstream.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// verifying the request may replace the body with one backed by
+	// a temporary file, which is removed when the body is closed.
+	defer func() {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+	}()
+
+	// copy the signatures before they are removed by the verifier,
+	// as they are not covered by the signature.
+	sigInput := r.Header.Values("Signature-Input")
+	sig := r.Header.Values("Signature")
+
+	out, _, err := p.verifier.Parse(w, r, time.Now())
+	if err != nil {
+		if p.onValidationError != nil {
+			p.onValidationError(r.Context(), err)
+		}
+		p.onFailure(w, r, err)
+		return
+	}
+
+	if p.keepSignatures {
+		out.Header = out.Header.Clone()
+		out.Header.Del("Signature-Input")
+		out.Header.Del("Signature")
+		for _, v := range sigInput {
+			out.Header.Add("Signature-Input", v)
+		}
+		for _, v := range sig {
+			out.Header.Add("Signature", v)
+		}
+	}
+
+	// don't forward a request body which isn't covered by the signature.
+	if _, ok := out.Body.(verifier.UncoveredBody); ok {
+		out.Body = http.NoBody
+		out.ContentLength = 0
+	}
+
+	p.rp.ServeHTTP(w, out)
+}
+
+// requireRequestTarget returns an error if the verifier accepts signatures
+// which do not cover the '@method' and '@target-uri' components.
+func requireRequestTarget(v *verifier.Verifier) error {
+	validations := []sigparams.ValidateOpts{v.Validation}
+
+	if len(v.Policies) > 0 {
+		validations = nil
+		for _, p := range v.Policies {
+			if p.Validation == nil {
+				validations = append(validations, v.Validation)
+				continue
+			}
+			validations = append(validations, *p.Validation)
+		}
+	}
+
+	for _, opts := range validations {
+		for _, c := range []string{"@method", "@target-uri"} {
+			if !opts.RequiredCoveredComponents[c] {
+				return fmt.Errorf("sigproxy: Verifier must require the %q component to be covered", c)
+			}
+		}
+	}
+
+	return nil
+}
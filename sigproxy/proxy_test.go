@@ -0,0 +1,309 @@
+package sigproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig"
+	"github.com/common-fate/httpsig/alg_hmac"
+	"github.com/common-fate/httpsig/inmemory"
+	"github.com/common-fate/httpsig/signer"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+	"github.com/common-fate/httpsig/verifier"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProxy(t *testing.T) {
+	clientKey := alg_hmac.NewHMAC([]byte("client-secret"))
+	gatewayKey := alg_hmac.NewHMAC([]byte("gateway-secret"))
+
+	withBody := []string{"@method", "@target-uri", "content-type", "content-length", "content-digest"}
+	withoutBody := []string{"@method", "@target-uri"}
+
+	validation := sigparams.ValidateOpts{
+		BeforeDuration:            time.Minute,
+		RequiredCoveredComponents: map[string]bool{"@method": true, "@target-uri": true},
+		RequireNonce:              true,
+	}
+
+	var (
+		mu       sync.Mutex
+		upstream *http.Request
+	)
+
+	mux := http.NewServeMux()
+	upstreamServer := httptest.NewServer(mux)
+	defer upstreamServer.Close()
+
+	// the upstream service only trusts the gateway key.
+	verifyGateway := httpsig.Middleware(httpsig.MiddlewareOpts{
+		NonceStorage: inmemory.NewNonceStorage(),
+		KeyDirectory: alg_hmac.NewSingleHMACKeyDirectory(gatewayKey),
+		Tag:          "gateway",
+		Validation:   &validation,
+		Scheme:       "http",
+		Authority:    strings.TrimPrefix(upstreamServer.URL, "http://"),
+	})
+
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		upstream = r.Clone(r.Context())
+		mu.Unlock()
+
+		verifyGateway(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				body = []byte(err.Error())
+			}
+			_, _ = w.Write(body)
+		})).ServeHTTP(w, r)
+	}))
+
+	target, err := url.Parse(upstreamServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		clientAlg        signer.Algorithm
+		clientComponents []string
+		keepSignatures   bool
+		wantStatus       int
+		wantBody         string
+		wantUpstream     bool
+		wantLabels       []string
+	}{
+		{
+			name:             "ok",
+			clientAlg:        clientKey,
+			clientComponents: withBody,
+			wantStatus:       http.StatusOK,
+			wantBody:         `{"item":"book"}`,
+			wantUpstream:     true,
+			wantLabels:       []string{"sig1"},
+		},
+		{
+			name:             "keep_signatures",
+			clientAlg:        clientKey,
+			clientComponents: withBody,
+			keepSignatures:   true,
+			wantStatus:       http.StatusOK,
+			wantBody:         `{"item":"book"}`,
+			wantUpstream:     true,
+			wantLabels:       []string{"sig1", "sig2"},
+		},
+		{
+			name:             "uncovered_body_is_not_forwarded",
+			clientAlg:        clientKey,
+			clientComponents: withoutBody,
+			wantStatus:       http.StatusOK,
+			wantBody:         "",
+			wantUpstream:     true,
+			wantLabels:       []string{"sig1"},
+		},
+		{
+			name:             "invalid_client_signature",
+			clientAlg:        alg_hmac.NewHMAC([]byte("wrong-secret")),
+			clientComponents: withBody,
+			wantStatus:       http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			upstream = nil
+			mu.Unlock()
+
+			gatewaySigner := &signer.Transport{
+				KeyID:             "gateway",
+				Tag:               "gateway",
+				Alg:               gatewayKey,
+				CoveredComponents: []string{"@method", "@target-uri", "content-length", "content-digest"},
+			}
+			if tt.keepSignatures {
+				gatewaySigner.CoveredSignatureTags = []string{"client"}
+			}
+
+			gatewayServer := httptest.NewUnstartedServer(nil)
+			defer gatewayServer.Close()
+
+			p, err := New(Opts{
+				Target: target,
+				Verifier: &verifier.Verifier{
+					NonceStorage: inmemory.NewNonceStorage(),
+					KeyDirectory: alg_hmac.NewSingleHMACKeyDirectory(clientKey),
+					Tag:          "client",
+					Validation:   validation,
+					Scheme:       "http",
+					Authority:    gatewayServer.Listener.Addr().String(),
+				},
+				Signer:         gatewaySigner,
+				KeepSignatures: tt.keepSignatures,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gatewayServer.Config.Handler = p
+			gatewayServer.Start()
+
+			client := &http.Client{
+				Transport: &signer.Transport{
+					KeyID:             "client",
+					Tag:               "client",
+					Alg:               tt.clientAlg,
+					CoveredComponents: tt.clientComponents,
+				},
+			}
+
+			req, err := http.NewRequest(http.MethodPost, gatewayServer.URL+"/orders", strings.NewReader(`{"item":"book"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Unsigned", "true")
+
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", res.StatusCode, tt.wantStatus, body)
+			}
+
+			mu.Lock()
+			got := upstream
+			mu.Unlock()
+
+			if (got != nil) != tt.wantUpstream {
+				t.Fatalf("upstream called = %v, want %v", got != nil, tt.wantUpstream)
+			}
+			if got == nil {
+				return
+			}
+
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+
+			if v := got.Header.Get("X-Unsigned"); v != "" {
+				t.Errorf("unsigned header was forwarded upstream: %q", v)
+			}
+
+			set, err := sigset.Unmarshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.wantLabels, set.Labels()); diff != "" {
+				t.Errorf("upstream signature labels mismatch (-want +got):\n%s", diff)
+			}
+
+			// the gateway signature attests to the client signature.
+			if tt.keepSignatures && !set.Get("sig2").Input.CoversSignature("sig1") {
+				t.Errorf("gateway signature did not cover the client signature")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	target, err := url.Parse("http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requireTarget := sigparams.ValidateOpts{
+		RequiredCoveredComponents: map[string]bool{"@method": true, "@target-uri": true},
+	}
+	requireMethod := sigparams.ValidateOpts{
+		RequiredCoveredComponents: map[string]bool{"@method": true},
+	}
+
+	tests := []struct {
+		name    string
+		opts    Opts
+		wantErr bool
+	}{
+		{
+			name:    "target_not_provided",
+			opts:    Opts{},
+			wantErr: true,
+		},
+		{
+			name: "ok",
+			opts: Opts{
+				Target:   target,
+				Verifier: &verifier.Verifier{Validation: requireTarget},
+				Signer:   &signer.Transport{},
+			},
+		},
+		{
+			name: "covered_components_not_required",
+			opts: Opts{
+				Target:   target,
+				Verifier: &verifier.Verifier{},
+				Signer:   &signer.Transport{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "target_uri_not_required",
+			opts: Opts{
+				Target:   target,
+				Verifier: &verifier.Verifier{Validation: requireMethod},
+				Signer:   &signer.Transport{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "policy_uses_verifier_validation",
+			opts: Opts{
+				Target: target,
+				Verifier: &verifier.Verifier{
+					Validation: requireTarget,
+					Policies:   []verifier.Policy{{Tag: "client"}},
+				},
+				Signer: &signer.Transport{},
+			},
+		},
+		{
+			name: "policy_does_not_require_target_uri",
+			opts: Opts{
+				Target: target,
+				Verifier: &verifier.Verifier{
+					Validation: requireTarget,
+					Policies: []verifier.Policy{
+						{Tag: "client"},
+						{Tag: "partner", Validation: &requireMethod},
+					},
+				},
+				Signer: &signer.Transport{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
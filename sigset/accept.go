@@ -0,0 +1,72 @@
+package sigset
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/dunglas/httpsfv"
+)
+
+// IncludeAccept includes requested signatures in a HTTP header
+// by setting the Accept-Signature field.
+//
+// The index of the map is the label which the signer is requested
+// to use for the signature.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-5.1
+func IncludeAccept(h http.Header, requests map[string]sigparams.AcceptSignature) error {
+	dict := httpsfv.NewDictionary()
+
+	labels := make([]string, 0, len(requests))
+	for k := range requests {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		dict.Add(label, requests[label].SFV())
+	}
+
+	val, err := httpsfv.Marshal(dict)
+	if err != nil {
+		return fmt.Errorf("marshalling Accept-Signature header: %w", err)
+	}
+
+	h.Set("Accept-Signature", val)
+
+	return nil
+}
+
+// UnmarshalAccept unmarshals the requested signatures
+// from the Accept-Signature field in a HTTP header.
+//
+// The index of the returned map is the label which the
+// signer is requested to use for the signature.
+func UnmarshalAccept(h http.Header) (map[string]*sigparams.AcceptSignature, error) {
+	dict, err := httpsfv.UnmarshalDictionary(h.Values("Accept-Signature"))
+	if err != nil {
+		return nil, fmt.Errorf("Accept-Signature header is malformed: %w", err)
+	}
+
+	requests := map[string]*sigparams.AcceptSignature{}
+
+	for _, label := range dict.Names() {
+		val, _ := dict.Get(label)
+
+		list, ok := val.(httpsfv.InnerList)
+		if !ok {
+			return nil, fmt.Errorf("could not cast Accept-Signature field %s to a httpsfv.InnerList, got type %T", label, val)
+		}
+
+		a, err := sigparams.UnmarshalAcceptInnerList(list)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling requested signature %q: %w", label, err)
+		}
+
+		requests[label] = a
+	}
+
+	return requests, nil
+}
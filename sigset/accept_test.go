@@ -0,0 +1,97 @@
+package sigset
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIncludeAccept(t *testing.T) {
+	requests := map[string]sigparams.AcceptSignature{
+		"sig1": {
+			CoveredComponents: []string{"@method", "@target-uri", "content-digest", "@query-param;name=\"foo\""},
+			KeyID:             "test-key",
+			Alg:               "ecdsa-p256-sha256",
+			Tag:               "app-123",
+			Created:           true,
+			Nonce:             true,
+		},
+	}
+
+	h := http.Header{}
+
+	err := IncludeAccept(h, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `sig1=("@method" "@target-uri" "content-digest" "@query-param";name="foo");keyid="test-key";alg="ecdsa-p256-sha256";tag="app-123";nonce;created`
+	if diff := cmp.Diff(want, h.Get("Accept-Signature")); diff != "" {
+		t.Errorf("Accept-Signature mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := UnmarshalAccept(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantParsed := requests["sig1"]
+	if diff := cmp.Diff(&wantParsed, got["sig1"]); diff != "" {
+		t.Errorf("UnmarshalAccept() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUnmarshalAccept(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    map[string]*sigparams.AcceptSignature
+		wantErr bool
+	}{
+		{
+			name:   "rfc_example",
+			header: `sig1=("@method" "@target-uri" "@authority" "content-digest" "cache-control");keyid="test-key-rsa-pss";created;tag="app-123"`,
+			want: map[string]*sigparams.AcceptSignature{
+				"sig1": {
+					CoveredComponents: []string{"@method", "@target-uri", "@authority", "content-digest", "cache-control"},
+					KeyID:             "test-key-rsa-pss",
+					Tag:               "app-123",
+					Created:           true,
+				},
+			},
+		},
+		{
+			name:   "empty",
+			header: "",
+			want:   map[string]*sigparams.AcceptSignature{},
+		},
+		{
+			name:    "not_an_inner_list",
+			header:  `sig1="@method"`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			header:  `sig1=(`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.header != "" {
+				h.Set("Accept-Signature", tt.header)
+			}
+
+			got, err := UnmarshalAccept(h)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalAccept() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("UnmarshalAccept() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -9,11 +9,21 @@ import (
 // Add a signature message to the set.
 //
 // Add() gives a label such as 'sig1'
-// 'sig2', 'sig3' etc to the message.
+// 'sig2', 'sig3' etc to the message, skipping
+// any labels which are already in use.
 //
-// To customise the label, add the message
-// to the Messages field directly.
+// To customise the label, use AddWithLabel.
 func (s *Set) Add(m *signature.Message) {
-	label := "sig" + strconv.Itoa(len(s.Messages)+1)
-	s.Messages[label] = m
+	_ = s.AddWithLabel(s.NextLabel(), m)
+}
+
+// NextLabel returns the label which Add will give
+// to the next signature added to the set.
+func (s *Set) NextLabel() string {
+	for n := len(s.Messages) + 1; ; n++ {
+		label := "sig" + strconv.Itoa(n)
+		if _, ok := s.Messages[label]; !ok {
+			return label
+		}
+	}
 }
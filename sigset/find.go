@@ -24,14 +24,23 @@ func (e MultipleSignaturesError) Error() string {
 //
 // Returns an error if the supplied tag is empty.
 func (s *Set) Find(tag string) (*signature.Message, error) {
+	_, m, err := s.FindWithLabel(tag)
+	return m, err
+}
+
+// FindWithLabel finds a signature matching the tag,
+// returning the label of the signature along with the signature.
+//
+// It returns the same errors as Find.
+func (s *Set) FindWithLabel(tag string) (string, *signature.Message, error) {
 	if tag == "" {
-		return nil, errors.New("tag to find was empty")
+		return "", nil, errors.New("tag to find was empty")
 	}
 
 	var found string
 
-	for k, v := range s.Messages {
-		if v.Input.Tag != tag {
+	for _, k := range s.Labels() {
+		if s.Messages[k].Input.Tag != tag {
 			continue
 		}
 
@@ -42,15 +51,15 @@ func (s *Set) Find(tag string) (*signature.Message, error) {
 				First:  found,
 				Second: k,
 			}
-			return nil, err
+			return "", nil, err
 		}
 
 		found = k
 	}
 
 	if found == "" {
-		return nil, fmt.Errorf("could not find a signature matching the tag %q", tag)
+		return "", nil, fmt.Errorf("could not find a signature matching the tag %q", tag)
 	}
 
-	return s.Messages[found], nil
+	return found, s.Messages[found], nil
 }
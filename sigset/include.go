@@ -13,12 +13,23 @@ import (
 // If Signature-Input or Signature headers exist they
 // will be overwritten.
 func (s *Set) Include(r *http.Request) error {
+	return s.IncludeHeader(r.Header)
+}
+
+// IncludeHeader includes a signature set in a HTTP header by
+// setting the Signature-Input and Signature fields.
+//
+// It can be used to include the signatures on a HTTP response.
+//
+// Signatures are included in the order given by Labels.
+func (s *Set) IncludeHeader(h http.Header) error {
 	sigInputDict := httpsfv.NewDictionary()
 	sigDict := httpsfv.NewDictionary()
 
-	for k, v := range s.Messages {
-		sigInputDict.Add(k, v.Input.SFV())
-		sigDict.Add(k, httpsfv.NewItem(v.Signature))
+	for _, label := range s.Labels() {
+		m := s.Messages[label]
+		sigInputDict.Add(label, m.Input.SFV())
+		sigDict.Add(label, httpsfv.NewItem(m.Signature))
 	}
 
 	sigInputString, err := httpsfv.Marshal(sigInputDict)
@@ -26,14 +37,14 @@ func (s *Set) Include(r *http.Request) error {
 		return fmt.Errorf("marshalling Signature-Input header: %w", err)
 	}
 
-	r.Header.Set("Signature-Input", sigInputString)
+	h.Set("Signature-Input", sigInputString)
 
 	sigString, err := httpsfv.Marshal(sigDict)
 	if err != nil {
 		return fmt.Errorf("marshalling Signature header: %w", err)
 	}
 
-	r.Header.Set("Signature", sigString)
+	h.Set("Signature", sigString)
 
 	return nil
 }
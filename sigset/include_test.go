@@ -80,7 +80,7 @@ func TestMessage_Include(t *testing.T) {
 				t.Fatalf("unmarshal error: %s", err)
 			}
 
-			if diff := cmp.Diff(&tc.set, got); diff != "" {
+			if diff := cmp.Diff(tc.set.Messages, got.Messages); diff != "" {
 				t.Errorf("verifier.Parse() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -2,13 +2,85 @@
 // describing a set of HTTP message signatures.
 package sigset
 
-import "github.com/common-fate/httpsig/signature"
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/common-fate/httpsig/signature"
+)
+
+// ErrDuplicateLabel is returned by AddWithLabel if
+// the set already contains a signature with the label.
+var ErrDuplicateLabel = errors.New("signature label is already in use")
 
 // Set of signatures in an HTTP request.
 //
 // The index of the map is the label given to the signatures.
 // When parsing signatures do not rely upon the label,
 // use the tag in the signature params instead.
+//
+// The order of labels is preserved when signatures are unmarshalled
+// or added using Add and AddWithLabel, so that intermediaries adding
+// signatures don't reorder the existing signatures.
 type Set struct {
 	Messages map[string]*signature.Message
+
+	// labels is the order that labels were added to the set.
+	labels []string
+}
+
+// Labels returns the labels of the signatures in the set, in order.
+//
+// Signatures which were added to the Messages field directly
+// are ordered after the other signatures, sorted by label.
+func (s *Set) Labels() []string {
+	labels := make([]string, 0, len(s.Messages))
+	ordered := make(map[string]bool, len(s.labels))
+
+	for _, label := range s.labels {
+		if _, ok := s.Messages[label]; ok && !ordered[label] {
+			labels = append(labels, label)
+			ordered[label] = true
+		}
+	}
+
+	var unordered []string
+	for label := range s.Messages {
+		if !ordered[label] {
+			unordered = append(unordered, label)
+		}
+	}
+	sort.Strings(unordered)
+
+	return append(labels, unordered...)
+}
+
+// Get returns the signature with the given label,
+// or nil if the set does not contain the label.
+func (s *Set) Get(label string) *signature.Message {
+	return s.Messages[label]
+}
+
+// AddWithLabel adds a signature message to the set using the given label.
+//
+// An error wrapping ErrDuplicateLabel is returned if
+// the label is already in use.
+func (s *Set) AddWithLabel(label string, m *signature.Message) error {
+	if label == "" {
+		return errors.New("signature label was empty")
+	}
+
+	if _, ok := s.Messages[label]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateLabel, label)
+	}
+
+	if s.Messages == nil {
+		s.Messages = map[string]*signature.Message{}
+	}
+
+	s.Messages[label] = m
+	s.labels = append(s.labels, label)
+
+	return nil
 }
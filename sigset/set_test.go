@@ -0,0 +1,109 @@
+package sigset
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/common-fate/httpsig/signature"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSet_Labels(t *testing.T) {
+	type testcase struct {
+		name string
+		// give sets the Signature-Input and Signature fields.
+		give   http.Header
+		add    []string
+		direct []string
+		want   []string
+	}
+	testcases := []testcase{
+		{
+			name: "preserves_parsed_order",
+			give: http.Header{
+				"Signature-Input": {`zeta=("@method");keyid="a", alpha=("@method");keyid="b"`},
+				"Signature":       {`zeta=:YQ==:, alpha=:Yg==:`},
+			},
+			want: []string{"zeta", "alpha"},
+		},
+		{
+			name: "added_after_parsed",
+			give: http.Header{
+				"Signature-Input": {`sig2=("@method");keyid="a"`},
+				"Signature":       {`sig2=:YQ==:`},
+			},
+			add:  []string{"proxy"},
+			want: []string{"sig2", "proxy"},
+		},
+		{
+			name:   "messages_added_directly_are_sorted",
+			add:    []string{"sig1"},
+			direct: []string{"b", "a"},
+			want:   []string{"sig1", "a", "b"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			set, err := UnmarshalHeader(tc.give)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, label := range tc.add {
+				err = set.AddWithLabel(label, &signature.Message{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, label := range tc.direct {
+				set.Messages[label] = &signature.Message{}
+			}
+
+			if diff := cmp.Diff(tc.want, set.Labels()); diff != "" {
+				t.Errorf("Set.Labels() mismatch (-want +got):\n%s", diff)
+			}
+
+			// the signatures must be included in the same order.
+			h := http.Header{}
+			err = set.IncludeHeader(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := UnmarshalHeader(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, got.Labels()); diff != "" {
+				t.Errorf("included labels mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSet_Add(t *testing.T) {
+	var set Set
+
+	set.Add(&signature.Message{})
+
+	err := set.AddWithLabel("sig2", &signature.Message{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sig2 is in use, so the next label is sig3.
+	set.Add(&signature.Message{})
+
+	if diff := cmp.Diff([]string{"sig1", "sig2", "sig3"}, set.Labels()); diff != "" {
+		t.Errorf("Set.Labels() mismatch (-want +got):\n%s", diff)
+	}
+
+	err = set.AddWithLabel("sig1", &signature.Message{})
+	if !errors.Is(err, ErrDuplicateLabel) {
+		t.Errorf("AddWithLabel() err = %v, want %v", err, ErrDuplicateLabel)
+	}
+}
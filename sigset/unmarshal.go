@@ -26,13 +26,21 @@ import (
 // the Signature field, and each field MUST contain the same labels.
 // The presence of a label in one field but not the other is an error.
 func Unmarshal(r *http.Request) (*Set, error) {
-	sigInputDict, err := httpsfv.UnmarshalDictionary(r.Header.Values("Signature-Input"))
+	return UnmarshalHeader(r.Header)
+}
+
+// UnmarshalHeader unmarshals a set of signatures from the
+// Signature-Input and Signature fields in a HTTP header.
+//
+// It can be used to read the signatures on a HTTP response.
+func UnmarshalHeader(h http.Header) (*Set, error) {
+	sigInputDict, err := httpsfv.UnmarshalDictionary(h.Values("Signature-Input"))
 	if err != nil {
 		// refuse to sign a request if the existing Signature-Input is malformed.
 		return nil, fmt.Errorf("Signature-Input header is malformed: %w", err)
 	}
 
-	sigDict, err := httpsfv.UnmarshalDictionary(r.Header.Values("Signature"))
+	sigDict, err := httpsfv.UnmarshalDictionary(h.Values("Signature"))
 	if err != nil {
 		// refuse to sign a request if the existing Signature is malformed.
 		return nil, fmt.Errorf("Signature header but it is malformed: %w", err)
@@ -46,6 +54,9 @@ func Unmarshal(r *http.Request) (*Set, error) {
 		Messages: map[string]*signature.Message{},
 	}
 
+	// the order of labels is preserved, so that the signatures
+	// are included in the same order if the set is re-serialized.
+
 	for _, field := range sigInputDict.Names() {
 		val, _ := sigInputDict.Get(field)
 
@@ -76,9 +87,12 @@ func Unmarshal(r *http.Request) (*Set, error) {
 			return nil, fmt.Errorf("could not cast signature %q to bytes", field)
 		}
 
-		s.Messages[field] = &signature.Message{
+		err = s.AddWithLabel(field, &signature.Message{
 			Input:     *p,
 			Signature: sigBytes,
+		})
+		if err != nil {
+			return nil, err
 		}
 
 		parsedInputs[field] = true
@@ -0,0 +1,105 @@
+package sqlnonce
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect is a SQL dialect supported by NonceStorage.
+type Dialect string
+
+const (
+	// Postgres uses 'INSERT ... ON CONFLICT DO NOTHING'.
+	Postgres Dialect = "postgres"
+
+	// MySQL uses 'INSERT IGNORE'.
+	MySQL Dialect = "mysql"
+
+	// SQLite uses 'INSERT ... ON CONFLICT DO NOTHING',
+	// which requires SQLite 3.24.0 or later.
+	SQLite Dialect = "sqlite"
+)
+
+func (d Dialect) validate() error {
+	switch d {
+	case Postgres, MySQL, SQLite:
+		return nil
+	}
+	return fmt.Errorf("unsupported SQL dialect %q", d)
+}
+
+// placeholder returns the bind parameter for the nth argument, starting from 1.
+func (d Dialect) placeholder(n int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// insertQuery returns a statement which inserts a nonce, ignoring the
+// insert if the nonce already exists.
+//
+// The arguments are the key ID, nonce, and expiry in Unix milliseconds.
+func (d Dialect) insertQuery(table string) string {
+	values := fmt.Sprintf("(key_id, nonce, expires_at) VALUES (%s, %s, %s)", d.placeholder(1), d.placeholder(2), d.placeholder(3))
+
+	if d == MySQL {
+		return "INSERT IGNORE INTO " + table + " " + values
+	}
+
+	return "INSERT INTO " + table + " " + values + " ON CONFLICT (key_id, nonce) DO NOTHING"
+}
+
+// purgeQuery returns a statement which deletes expired nonces.
+//
+// The argument is the current time in Unix milliseconds.
+func (d Dialect) purgeQuery(table string) string {
+	return "DELETE FROM " + table + " WHERE expires_at < " + d.placeholder(1)
+}
+
+// schema returns the statements which create the nonce table.
+//
+// If the table is qualified with a schema, the index is created in the
+// same schema as the table, as the index name can't be qualified in
+// Postgres and MySQL, and the table name can't be qualified in SQLite.
+func (d Dialect) schema(table string) []string {
+	schema, name := "", table
+	if i := strings.LastIndex(table, "."); i != -1 {
+		schema, name = table[:i+1], table[i+1:]
+	}
+
+	index := name + "_expires_at_idx"
+
+	switch d {
+	case MySQL:
+		// VARBINARY is used so that nonces are compared case-sensitively.
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + table + " (" +
+				"key_id VARBINARY(255) NOT NULL, " +
+				"nonce VARBINARY(255) NOT NULL, " +
+				"expires_at BIGINT NOT NULL, " +
+				"PRIMARY KEY (key_id, nonce), " +
+				"INDEX " + index + " (expires_at))",
+		}
+
+	case SQLite:
+		return []string{
+			"CREATE TABLE IF NOT EXISTS " + table + " (" +
+				"key_id TEXT NOT NULL, " +
+				"nonce TEXT NOT NULL, " +
+				"expires_at INTEGER NOT NULL, " +
+				"PRIMARY KEY (key_id, nonce))",
+			"CREATE INDEX IF NOT EXISTS " + schema + index + " ON " + name + " (expires_at)",
+		}
+	}
+
+	return []string{
+		"CREATE TABLE IF NOT EXISTS " + table + " (" +
+			"key_id TEXT NOT NULL, " +
+			"nonce TEXT NOT NULL, " +
+			"expires_at BIGINT NOT NULL, " +
+			"PRIMARY KEY (key_id, nonce))",
+		"CREATE INDEX IF NOT EXISTS " + index + " ON " + table + " (expires_at)",
+	}
+}
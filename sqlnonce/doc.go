@@ -0,0 +1,32 @@
+/*
+Package sqlnonce provides a verifier.NonceStorage backed by a SQL database
+using database/sql, allowing replay protection to be shared between
+multiple servers without running additional infrastructure.
+
+Nonces are stored in a table with a unique (key_id, nonce) primary key, and
+are inserted using a dialect-specific insert-if-absent statement. If no row
+was inserted, the nonce has been seen before.
+
+The table can be created using NonceStorage.Migrate, and expired rows are
+periodically deleted by a background purge.
+
+The database driver is not imported by this package, so any driver
+for a supported dialect can be used:
+
+	db, err := sql.Open("pgx", "postgres://localhost:5432/app")
+	if err != nil {
+		return err
+	}
+
+	nonces, err := sqlnonce.New(db, sqlnonce.Opts{Dialect: sqlnonce.Postgres})
+	if err != nil {
+		return err
+	}
+	defer nonces.Close()
+
+	err = nonces.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+*/
+package sqlnonce
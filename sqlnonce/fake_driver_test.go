@@ -0,0 +1,101 @@
+package sqlnonce
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// fakeDB is an in-memory database/sql driver which supports the
+// statements executed by NonceStorage, recording each statement.
+type fakeDB struct {
+	mu      sync.Mutex
+	queries []string
+	// rows maps key ID and nonce to the expiry in Unix milliseconds.
+	rows map[[2]string]int64
+}
+
+// openFakeDB returns a *sql.DB backed by a new fakeDB.
+func openFakeDB() (*sql.DB, *fakeDB) {
+	f := &fakeDB{rows: map[[2]string]int64{}}
+	return sql.OpenDB(f), f
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return nil
+}
+
+// Queries returns the statements which have been executed.
+func (f *fakeDB) Queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.queries...)
+}
+
+func (f *fakeDB) exec(query string, args []driver.NamedValue) (driver.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries = append(f.queries, query)
+
+	switch {
+	case strings.HasPrefix(query, "CREATE "):
+		return driver.RowsAffected(0), nil
+
+	case strings.HasPrefix(query, "INSERT "):
+		if len(args) != 3 {
+			return nil, fmt.Errorf("expected 3 arguments, got %d", len(args))
+		}
+		key := [2]string{args[0].Value.(string), args[1].Value.(string)}
+		if _, ok := f.rows[key]; ok {
+			return driver.RowsAffected(0), nil
+		}
+		f.rows[key] = args[2].Value.(int64)
+		return driver.RowsAffected(1), nil
+
+	case strings.HasPrefix(query, "DELETE "):
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		var n int64
+		for key, expiresAt := range f.rows {
+			if expiresAt < args[0].Value.(int64) {
+				delete(f.rows, key)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	}
+
+	return nil, fmt.Errorf("unsupported statement: %s", query)
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+var _ driver.ExecerContext = fakeConn{}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.db.exec(query, args)
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
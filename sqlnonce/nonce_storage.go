@@ -0,0 +1,229 @@
+package sqlnonce
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/common-fate/httpsig/verifier"
+)
+
+// getCurrentTime allows the current time to be overridden for testing.
+var getCurrentTime = time.Now
+
+const (
+	// DefaultTTL is the default duration that nonces are stored for.
+	DefaultTTL = 2 * time.Minute
+
+	// DefaultTable is the default name of the nonce table.
+	DefaultTable = "httpsig_nonces"
+
+	// DefaultPurgeInterval is the default interval between
+	// deleting expired nonces.
+	DefaultPurgeInterval = 5 * time.Minute
+
+	// MaxLength is the maximum length in bytes of
+	// the nonce and key ID which can be stored.
+	MaxLength = 255
+)
+
+// ErrTooLong is returned by NonceStorage.Seen if the nonce
+// or key ID is longer than MaxLength.
+var ErrTooLong = errors.New("nonce or key ID is too long to be stored")
+
+// validTable matches table names which are safe to
+// use in a statement without quoting.
+var validTable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type Opts struct {
+	// Dialect is the SQL dialect of the database. Required.
+	Dialect Dialect
+
+	// Table is the name of the nonce table, optionally qualified
+	// with a schema, such as 'auth.httpsig_nonces'.
+	//
+	// If empty, DefaultTable is used.
+	Table string
+
+	// TTL is the minimum duration that a nonce is stored for,
+	// measured from the time the signature was created.
+	//
+	// TTL should be at least ValidateOpts.BeforeDuration + ValidateOpts.AfterDuration.
+	//
+	// If zero, DefaultTTL is used.
+	TTL time.Duration
+
+	// PurgeInterval is the interval between deleting expired nonces.
+	//
+	// If zero, DefaultPurgeInterval is used.
+	PurgeInterval time.Duration
+
+	// DisablePurge disables the background goroutine which
+	// deletes expired nonces. Purge can be called directly instead.
+	DisablePurge bool
+
+	// OnPurgeError, if set, is called if the background
+	// purge fails to delete expired nonces.
+	OnPurgeError func(err error)
+}
+
+// NonceStorage implements the verifier.NonceStorage interface
+// by storing nonces in a SQL database.
+//
+// Nonces are kept until they are deleted by Purge, so a nonce which has
+// expired but not yet been purged is still reported as seen.
+type NonceStorage struct {
+	db      *sql.DB
+	dialect Dialect
+	table   string
+	ttl     time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+var _ verifier.NonceStorage = &NonceStorage{}
+var _ verifier.NonceRecordStorage = &NonceStorage{}
+
+// New creates a NonceStorage and starts the background purge,
+// unless it is disabled.
+//
+// Call Close to stop the background purge. Closing the NonceStorage
+// does not close the database.
+func New(db *sql.DB, opts Opts) (*NonceStorage, error) {
+	err := opts.Dialect.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	table := opts.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	if !validTable.MatchString(table) {
+		return nil, fmt.Errorf("invalid nonce table name %q", table)
+	}
+
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	s := &NonceStorage{
+		db:      db,
+		dialect: opts.Dialect,
+		table:   table,
+		ttl:     ttl,
+		stop:    make(chan struct{}),
+	}
+
+	if !opts.DisablePurge {
+		interval := opts.PurgeInterval
+		if interval <= 0 {
+			interval = DefaultPurgeInterval
+		}
+		go s.purger(interval, opts.OnPurgeError)
+	}
+
+	return s, nil
+}
+
+// Migrate creates the nonce table if it does not exist.
+func (s *NonceStorage) Migrate(ctx context.Context) error {
+	for _, stmt := range s.dialect.schema(s.table) {
+		_, err := s.db.ExecContext(ctx, stmt)
+		if err != nil {
+			return fmt.Errorf("creating nonce table: %w", err)
+		}
+	}
+	return nil
+}
+
+// Seen returns true if the nonce has been previously seen.
+func (s *NonceStorage) Seen(ctx context.Context, nonce string) (bool, error) {
+	return s.SeenWithContext(ctx, verifier.NonceRecord{Nonce: nonce})
+}
+
+// SeenWithContext returns true if the nonce has been previously seen.
+//
+// Nonces are scoped to the key ID of the signature.
+func (s *NonceStorage) SeenWithContext(ctx context.Context, record verifier.NonceRecord) (bool, error) {
+	if len(record.Nonce) > MaxLength || len(record.KeyID) > MaxLength {
+		return false, ErrTooLong
+	}
+
+	res, err := s.db.ExecContext(ctx, s.dialect.insertQuery(s.table), record.KeyID, record.Nonce, s.expiresAt(record).UnixMilli())
+	if err != nil {
+		return false, fmt.Errorf("storing nonce: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("storing nonce: %w", err)
+	}
+
+	// if the row was not inserted, the nonce already exists.
+	return n == 0, nil
+}
+
+// Purge deletes expired nonces, returning the number of nonces deleted.
+func (s *NonceStorage) Purge(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.dialect.purgeQuery(s.table), getCurrentTime().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("purging expired nonces: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purging expired nonces: %w", err)
+	}
+
+	return n, nil
+}
+
+// Close stops the background purge.
+func (s *NonceStorage) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	return nil
+}
+
+// expiresAt returns the time that the nonce can be deleted.
+//
+// If the signature was created in the future, the nonce is stored
+// until TTL has passed from the created time.
+func (s *NonceStorage) expiresAt(record verifier.NonceRecord) time.Time {
+	expiresAt := getCurrentTime().Add(s.ttl)
+
+	if !record.Created.IsZero() {
+		if fromCreated := record.Created.Add(s.ttl); fromCreated.After(expiresAt) {
+			expiresAt = fromCreated
+		}
+	}
+
+	return expiresAt
+}
+
+// purger periodically deletes expired nonces
+// until the storage is closed.
+func (s *NonceStorage) purger(interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			_, err := s.Purge(context.Background())
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package sqlnonce
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/verifier"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNonceStorage(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+	getCurrentTime = func() time.Time { return now }
+	defer func() { getCurrentTime = time.Now }()
+
+	db, fake := openFakeDB()
+	defer db.Close()
+
+	s, err := New(db, Opts{Dialect: Postgres, TTL: time.Minute, DisablePurge: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	seen := func(record verifier.NonceRecord) bool {
+		t.Helper()
+		got, err := s.SeenWithContext(ctx, record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	first := verifier.NonceRecord{Nonce: "first", KeyID: "alice", Created: now}
+
+	if seen(first) {
+		t.Fatal("expected first nonce not to be seen")
+	}
+	if !seen(first) {
+		t.Fatal("expected first nonce to be seen")
+	}
+
+	// nonces are scoped to the key ID.
+	if seen(verifier.NonceRecord{Nonce: "first", KeyID: "bob", Created: now}) {
+		t.Fatal("expected nonce not to be seen for a different key ID")
+	}
+
+	// a signature created in the future is stored until
+	// the TTL has passed from the created time.
+	future := verifier.NonceRecord{Nonce: "future", Created: now.Add(30 * time.Second)}
+	if seen(future) {
+		t.Fatal("expected future nonce not to be seen")
+	}
+
+	// nonces are not purged before they expire.
+	now = now.Add(59 * time.Second)
+	purged, err := s.Purge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 0 {
+		t.Fatalf("purged %d nonces, want 0", purged)
+	}
+	if !seen(first) {
+		t.Fatal("expected first nonce to be seen before the TTL")
+	}
+
+	now = now.Add(2 * time.Second)
+	purged, err = s.Purge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Fatalf("purged %d nonces, want 2", purged)
+	}
+	if seen(first) {
+		t.Fatal("expected first nonce to be forgotten after it was purged")
+	}
+	if !seen(future) {
+		t.Fatal("expected future nonce to be seen before the TTL from its created time")
+	}
+
+	_, err = s.Seen(ctx, strings.Repeat("a", MaxLength+1))
+	if !errors.Is(err, ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+
+	if got := len(fake.Queries()); got != 9 {
+		t.Fatalf("executed %d statements, want 9", got)
+	}
+}
+
+func TestNonceStorage_Dialects(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Opts
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "postgres",
+			opts: Opts{Dialect: Postgres},
+			want: []string{
+				"CREATE TABLE IF NOT EXISTS httpsig_nonces (key_id TEXT NOT NULL, nonce TEXT NOT NULL, expires_at BIGINT NOT NULL, PRIMARY KEY (key_id, nonce))",
+				"CREATE INDEX IF NOT EXISTS httpsig_nonces_expires_at_idx ON httpsig_nonces (expires_at)",
+				"INSERT INTO httpsig_nonces (key_id, nonce, expires_at) VALUES ($1, $2, $3) ON CONFLICT (key_id, nonce) DO NOTHING",
+				"DELETE FROM httpsig_nonces WHERE expires_at < $1",
+			},
+		},
+		{
+			name: "mysql",
+			opts: Opts{Dialect: MySQL, Table: "nonces"},
+			want: []string{
+				"CREATE TABLE IF NOT EXISTS nonces (key_id VARBINARY(255) NOT NULL, nonce VARBINARY(255) NOT NULL, expires_at BIGINT NOT NULL, PRIMARY KEY (key_id, nonce), INDEX nonces_expires_at_idx (expires_at))",
+				"INSERT IGNORE INTO nonces (key_id, nonce, expires_at) VALUES (?, ?, ?)",
+				"DELETE FROM nonces WHERE expires_at < ?",
+			},
+		},
+		{
+			name: "sqlite",
+			opts: Opts{Dialect: SQLite},
+			want: []string{
+				"CREATE TABLE IF NOT EXISTS httpsig_nonces (key_id TEXT NOT NULL, nonce TEXT NOT NULL, expires_at INTEGER NOT NULL, PRIMARY KEY (key_id, nonce))",
+				"CREATE INDEX IF NOT EXISTS httpsig_nonces_expires_at_idx ON httpsig_nonces (expires_at)",
+				"INSERT INTO httpsig_nonces (key_id, nonce, expires_at) VALUES (?, ?, ?) ON CONFLICT (key_id, nonce) DO NOTHING",
+				"DELETE FROM httpsig_nonces WHERE expires_at < ?",
+			},
+		},
+		{
+			name: "postgres_qualified_table",
+			opts: Opts{Dialect: Postgres, Table: "auth.httpsig_nonces"},
+			want: []string{
+				"CREATE TABLE IF NOT EXISTS auth.httpsig_nonces (key_id TEXT NOT NULL, nonce TEXT NOT NULL, expires_at BIGINT NOT NULL, PRIMARY KEY (key_id, nonce))",
+				"CREATE INDEX IF NOT EXISTS httpsig_nonces_expires_at_idx ON auth.httpsig_nonces (expires_at)",
+				"INSERT INTO auth.httpsig_nonces (key_id, nonce, expires_at) VALUES ($1, $2, $3) ON CONFLICT (key_id, nonce) DO NOTHING",
+				"DELETE FROM auth.httpsig_nonces WHERE expires_at < $1",
+			},
+		},
+		{
+			name: "mysql_qualified_table",
+			opts: Opts{Dialect: MySQL, Table: "auth.httpsig_nonces"},
+			want: []string{
+				"CREATE TABLE IF NOT EXISTS auth.httpsig_nonces (key_id VARBINARY(255) NOT NULL, nonce VARBINARY(255) NOT NULL, expires_at BIGINT NOT NULL, PRIMARY KEY (key_id, nonce), INDEX httpsig_nonces_expires_at_idx (expires_at))",
+				"INSERT IGNORE INTO auth.httpsig_nonces (key_id, nonce, expires_at) VALUES (?, ?, ?)",
+				"DELETE FROM auth.httpsig_nonces WHERE expires_at < ?",
+			},
+		},
+		{
+			name: "sqlite_qualified_table",
+			opts: Opts{Dialect: SQLite, Table: "auth.httpsig_nonces"},
+			want: []string{
+				"CREATE TABLE IF NOT EXISTS auth.httpsig_nonces (key_id TEXT NOT NULL, nonce TEXT NOT NULL, expires_at INTEGER NOT NULL, PRIMARY KEY (key_id, nonce))",
+				"CREATE INDEX IF NOT EXISTS auth.httpsig_nonces_expires_at_idx ON httpsig_nonces (expires_at)",
+				"INSERT INTO auth.httpsig_nonces (key_id, nonce, expires_at) VALUES (?, ?, ?) ON CONFLICT (key_id, nonce) DO NOTHING",
+				"DELETE FROM auth.httpsig_nonces WHERE expires_at < ?",
+			},
+		},
+		{
+			name:    "unsupported_dialect",
+			opts:    Opts{Dialect: "oracle"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid_table",
+			opts:    Opts{Dialect: Postgres, Table: "nonces; DROP TABLE users"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			db, fake := openFakeDB()
+			defer db.Close()
+
+			tt.opts.DisablePurge = true
+
+			s, err := New(db, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer s.Close()
+
+			err = s.Migrate(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.Seen(ctx, "nonce")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.Purge(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, fake.Queries()); diff != "" {
+				t.Errorf("statements mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNonceStorage_BackgroundPurge(t *testing.T) {
+	db, fake := openFakeDB()
+	defer db.Close()
+
+	s, err := New(db, Opts{Dialect: Postgres, PurgeInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(fake.Queries()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected expired nonces to be purged in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	s.Close()
+}
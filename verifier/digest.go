@@ -0,0 +1,60 @@
+package verifier
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/common-fate/httpsig/sigbase"
+)
+
+// declaresDigest returns true if the request contains a
+// Content-Digest or Repr-Digest header.
+//
+// Signers include these headers when the digest is covered, which
+// allows the digest algorithm to be chosen by the signer.
+func declaresDigest(req *http.Request) bool {
+	return len(req.Header.Values("Content-Digest")) > 0 || len(req.Header.Values("Repr-Digest")) > 0
+}
+
+// verifyDeclaredDigests verifies the request body against the covered
+// Content-Digest and Repr-Digest headers, using the strongest algorithm in
+// each header which is at least as strong as the key's digest algorithm.
+//
+// If v.StreamBody is true, r2.Body is wrapped so that the body is verified
+// as it is read. Otherwise, the body is read and r2.Body is replaced.
+func (v *Verifier) verifyDeclaredDigests(w http.ResponseWriter, req *http.Request, r2 *http.Request, base *sigbase.Base, key Algorithm) error {
+	for _, field := range []string{"Content-Digest", "Repr-Digest"} {
+		if _, ok := base.Values[strings.ToLower(field)]; !ok {
+			continue
+		}
+
+		// the representation can't be verified from a partial request.
+		if field == "Repr-Digest" && req.Header.Get("Content-Range") != "" {
+			continue
+		}
+
+		declared := req.Header.Values(field)
+
+		digester, err := v.contentDigest(key).Negotiate(declared)
+		if err != nil {
+			return fail(ReasonInvalidComponent, fmt.Errorf("verifying %s header: %w", field, err))
+		}
+
+		if v.StreamBody {
+			r2.Body, err = digester.NewVerifyingReader(r2.Body, declared)
+			if err != nil {
+				return fail(ReasonInvalidComponent, fmt.Errorf("verifying %s header: %w", field, err))
+			}
+			continue
+		}
+
+		err = digester.VerifyRequest(w, req, declared)
+		if err != nil {
+			return fail(bodyReason(err, ReasonInvalidComponent), fmt.Errorf("verifying %s header: %w", field, err))
+		}
+		r2.Body = req.Body
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package verifier
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+)
+
+// Reason is a machine-readable code describing why
+// a HTTP message signature could not be verified.
+//
+// Reasons are stable and can be used for metrics and
+// client-facing error codes.
+type Reason string
+
+const (
+	// ReasonUnknown is returned by ReasonOf if the error
+	// was not returned by the verifier.
+	ReasonUnknown Reason = "unknown"
+
+	// ReasonInternal indicates that verification failed due to
+	// an error in a dependency, such as the nonce storage.
+	ReasonInternal Reason = "internal_error"
+
+	ReasonInvalidAuthority      Reason = "invalid_authority"
+	ReasonMalformedSignature    Reason = "malformed_signature"
+	ReasonSignatureNotFound     Reason = "signature_not_found"
+	ReasonMultipleSignatures    Reason = "multiple_signatures"
+	ReasonInvalidParams         Reason = "invalid_params"
+	ReasonSignatureExpired      Reason = "signature_expired"
+	ReasonSignatureNotYetValid  Reason = "signature_not_yet_valid"
+	ReasonNonceRequired         Reason = "nonce_required"
+	ReasonNonceReplayed         Reason = "nonce_replayed"
+	ReasonMissingComponent      Reason = "missing_component"
+	ReasonKeyNotFound           Reason = "key_not_found"
+	ReasonAlgorithmMismatch     Reason = "algorithm_mismatch"
+	ReasonAlgorithmNotAllowed   Reason = "algorithm_not_allowed"
+	ReasonInvalidComponent      Reason = "invalid_component"
+	ReasonBodyTooLarge          Reason = "body_too_large"
+	ReasonContentDigestMismatch Reason = "content_digest_mismatch"
+	ReasonInvalidSignature      Reason = "invalid_signature"
+	ReasonSignatureNotCovered   Reason = "signature_not_covered"
+)
+
+var (
+	// ErrAuthorityMismatch is returned if the request host
+	// does not match the verifier's Authority.
+	ErrAuthorityMismatch = errors.New("request host did not match the expected authority")
+
+	// ErrMalformedSignature is returned if the Signature or
+	// Signature-Input fields could not be parsed.
+	ErrMalformedSignature = errors.New("malformed signature")
+
+	// ErrSignatureNotFound is returned if a signature matching
+	// the verifier's Tag could not be found.
+	ErrSignatureNotFound = errors.New("signature not found")
+
+	// ErrNonceReplayed is returned if the nonce
+	// of the signature has been seen before.
+	ErrNonceReplayed = errors.New("nonce has already been seen")
+
+	// ErrKeyNotFound should be returned by KeyDirectory implementations
+	// if a key matching the key ID could not be found.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrAlgorithmMismatch is returned if the 'alg' parameter
+	// of the signature does not match the algorithm of the key.
+	//
+	// KeyDirectory implementations should return an error wrapping
+	// ErrAlgorithmMismatch if the key can't be used with the 'alg' parameter.
+	ErrAlgorithmMismatch = errors.New("invalid algorithm signature parameter")
+
+	// ErrAlgorithmNotAllowed is returned if the algorithm of
+	// the key is not in the verifier's AllowedAlgorithms.
+	ErrAlgorithmNotAllowed = errors.New("signing algorithm is not allowed")
+
+	// ErrInvalidSignature is returned if the signature
+	// does not match the signature base.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrSignatureNotCovered is returned if a signature in
+	// Verifier.Chain is not covered by the signature after it.
+	ErrSignatureNotCovered = errors.New("signature in chain was not covered")
+
+	// Errors returned when validating the signature parameters.
+	ErrSignatureExpired       = sigparams.ErrSignatureExpired
+	ErrSignatureNotYetValid   = sigparams.ErrSignatureNotYetValid
+	ErrInvalidTimestamps      = sigparams.ErrInvalidTimestamps
+	ErrClientSideAlgForbidden = sigparams.ErrClientSideAlgForbidden
+	ErrNonceRequired          = sigparams.ErrNonceRequired
+
+	// ErrContentDigestMismatch is returned if a covered
+	// Content-Digest trailer does not match the message body.
+	ErrContentDigestMismatch = contentdigest.ErrDigestMismatch
+)
+
+// MissingComponentError is returned if a required
+// covered component was not included in the signature.
+type MissingComponentError = sigparams.MissingComponentError
+
+// MultipleSignaturesError is returned if multiple
+// signatures match the verifier's Tag.
+type MultipleSignaturesError = sigset.MultipleSignaturesError
+
+// Error is returned by the verifier when a HTTP message
+// signature could not be verified.
+//
+// Use errors.Is and errors.As with the sentinel errors and
+// error types in this package to inspect the cause of the error.
+type Error struct {
+	// Reason is a machine-readable code for the error.
+	Reason Reason
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ReasonOf returns the reason code for an error returned by the verifier.
+//
+// If the error was not returned by the verifier, ReasonUnknown is returned.
+func ReasonOf(err error) Reason {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Reason
+	}
+	return ReasonUnknown
+}
+
+// fail wraps an error with the reason that verification failed.
+func fail(reason Reason, err error) error {
+	return &Error{Reason: reason, Err: err}
+}
+
+// validationReason returns the reason code for an error
+// returned when validating the signature parameters.
+func validationReason(err error) Reason {
+	var missing *MissingComponentError
+
+	switch {
+	case errors.As(err, &missing):
+		return ReasonMissingComponent
+	case errors.Is(err, ErrSignatureExpired):
+		return ReasonSignatureExpired
+	case errors.Is(err, ErrSignatureNotYetValid):
+		return ReasonSignatureNotYetValid
+	case errors.Is(err, ErrNonceRequired):
+		return ReasonNonceRequired
+	}
+
+	return ReasonInvalidParams
+}
+
+// bodyReason returns the reason code for an error
+// returned when reading the message body.
+func bodyReason(err error, fallback Reason) Reason {
+	switch {
+	case errors.As(err, new(*http.MaxBytesError)):
+		return ReasonBodyTooLarge
+	case errors.Is(err, ErrContentDigestMismatch):
+		return ReasonContentDigestMismatch
+	}
+
+	return fallback
+}
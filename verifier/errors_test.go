@@ -0,0 +1,233 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigparams"
+)
+
+func TestVerifier_Parse_Errors(t *testing.T) {
+	const sigInput = `sig1=("@method" "@target-uri" "content-digest" "content-length");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";created=1704254706`
+
+	okAlgorithm := testAlgSelector{
+		Algorithm: testAlgorithm{
+			Digest:  contentdigest.SHA256,
+			AlgType: "ecdsa-p256-sha256",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		verifier   Verifier
+		header     http.Header
+		now        time.Time
+		wantReason Reason
+		wantIs     error
+	}{
+		{
+			name: "invalid_authority",
+			verifier: Verifier{
+				NonceStorage: testNonceStorage{},
+				KeyDirectory: okAlgorithm,
+				Authority:    "other.com",
+			},
+			wantReason: ReasonInvalidAuthority,
+			wantIs:     ErrAuthorityMismatch,
+		},
+		{
+			name: "malformed_signature",
+			header: http.Header{
+				"Signature":       {`sig1=:TU9DS19TSUdOQVRVUkU=:`},
+				"Signature-Input": {`sig1=(`},
+			},
+			wantReason: ReasonMalformedSignature,
+			wantIs:     ErrMalformedSignature,
+		},
+		{
+			name: "signature_not_found",
+			verifier: Verifier{
+				Tag: "other-app",
+			},
+			wantReason: ReasonSignatureNotFound,
+			wantIs:     ErrSignatureNotFound,
+		},
+		{
+			name: "signature_expired",
+			now:  time.Date(2024, 01, 03, 05, 05, 06, 00, time.UTC),
+			verifier: Verifier{
+				Validation: sigparams.ValidateOpts{BeforeDuration: time.Minute},
+			},
+			wantReason: ReasonSignatureExpired,
+			wantIs:     ErrSignatureExpired,
+		},
+		{
+			name: "nonce_required",
+			verifier: Verifier{
+				Validation: sigparams.ValidateOpts{RequireNonce: true},
+			},
+			wantReason: ReasonNonceRequired,
+			wantIs:     ErrNonceRequired,
+		},
+		{
+			name: "nonce_replayed",
+			verifier: Verifier{
+				NonceStorage: testNonceStorage{IsSeen: true},
+			},
+			wantReason: ReasonNonceReplayed,
+			wantIs:     ErrNonceReplayed,
+		},
+		{
+			name: "nonce_storage_error",
+			verifier: Verifier{
+				NonceStorage: testNonceStorage{Err: errors.New("nonce check error")},
+			},
+			wantReason: ReasonInternal,
+		},
+		{
+			name: "key_not_found",
+			verifier: Verifier{
+				KeyDirectory: testAlgSelector{Err: fmt.Errorf("%w: testkey-123", ErrKeyNotFound)},
+			},
+			wantReason: ReasonKeyNotFound,
+			wantIs:     ErrKeyNotFound,
+		},
+		{
+			name: "key_directory_algorithm_mismatch",
+			verifier: Verifier{
+				KeyDirectory: testAlgSelector{Err: fmt.Errorf("%w: unsupported algorithm", ErrAlgorithmMismatch)},
+			},
+			wantReason: ReasonAlgorithmMismatch,
+			wantIs:     ErrAlgorithmMismatch,
+		},
+		{
+			name: "key_directory_error",
+			verifier: Verifier{
+				KeyDirectory: testAlgSelector{Err: errors.New("key error")},
+			},
+			wantReason: ReasonInternal,
+		},
+		{
+			name: "algorithm_mismatch",
+			verifier: Verifier{
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Digest:  contentdigest.SHA256,
+						AlgType: "ed25519",
+					},
+				},
+			},
+			wantReason: ReasonAlgorithmMismatch,
+			wantIs:     ErrAlgorithmMismatch,
+		},
+		{
+			name: "declared_digest_weaker_than_key_digest",
+			verifier: Verifier{
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Digest:  contentdigest.SHA512,
+						AlgType: "ecdsa-p256-sha256",
+					},
+				},
+			},
+			header: http.Header{
+				"Content-Digest": {`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`},
+			},
+			wantReason: ReasonInvalidComponent,
+		},
+		{
+			name: "invalid_signature",
+			verifier: Verifier{
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Digest:  contentdigest.SHA256,
+						AlgType: "ecdsa-p256-sha256",
+						Err:     errors.New("verification error"),
+					},
+				},
+			},
+			wantReason: ReasonInvalidSignature,
+			wantIs:     ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.verifier
+			if v.NonceStorage == nil {
+				v.NonceStorage = testNonceStorage{}
+			}
+			if v.KeyDirectory == nil {
+				v.KeyDirectory = okAlgorithm
+			}
+			if v.Tag == "" {
+				v.Tag = "example-app"
+			}
+			if v.Authority == "" {
+				v.Authority = "example.com"
+			}
+			v.Scheme = "https"
+
+			now := tt.now
+			if now.IsZero() {
+				now = time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC)
+			}
+
+			req, _ := http.NewRequest("POST", "https://example.com", strings.NewReader(`{"hello": "world"}`))
+			req.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+			req.Header.Add("Signature-Input", sigInput)
+			for k, vals := range tt.header {
+				req.Header[k] = vals
+			}
+
+			_, _, err := v.Parse(nil, req, now)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if got := ReasonOf(err); got != tt.wantReason {
+				t.Errorf("ReasonOf() = %v, want %v (err = %s)", got, tt.wantReason, err)
+			}
+
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("expected errors.Is(%s, %s)", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestVerifier_Parse_MissingComponentError(t *testing.T) {
+	v := Verifier{
+		NonceStorage: testNonceStorage{},
+		KeyDirectory: testAlgSelector{},
+		Tag:          "example-app",
+		Authority:    "example.com",
+		Scheme:       "https",
+		Validation: sigparams.ValidateOpts{
+			RequiredCoveredComponents: map[string]bool{"content-type": true},
+		},
+	}
+
+	req, _ := http.NewRequest("POST", "https://example.com", nil)
+	req.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+	req.Header.Add("Signature-Input", `sig1=("@method" "@target-uri");tag="example-app";created=1704254706`)
+
+	_, _, err := v.Parse(nil, req, time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC))
+
+	var missing *MissingComponentError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected a MissingComponentError, got %v", err)
+	}
+
+	if missing.Component != "content-type" {
+		t.Errorf("Component = %q, want %q", missing.Component, "content-type")
+	}
+
+	if got := ReasonOf(err); got != ReasonMissingComponent {
+		t.Errorf("ReasonOf() = %v, want %v", got, ReasonMissingComponent)
+	}
+}
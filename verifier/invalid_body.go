@@ -4,7 +4,7 @@ import "errors"
 
 var ErrBodyNotCovered = errors.New("the body cannot be read because it is not covered by a HTTP signature: include 'content-digest' and 'content-length' in the signature to fix this")
 
-// UncoveredBody is an io.Reader which returns
+// UncoveredBody is an io.ReadCloser which returns
 // ErrBodyNotCovered when being read.
 //
 // It is used to guard against applications
@@ -15,3 +15,7 @@ type UncoveredBody struct {
 func (b UncoveredBody) Read(p []byte) (n int, err error) {
 	return 0, ErrBodyNotCovered
 }
+
+func (b UncoveredBody) Close() error {
+	return nil
+}
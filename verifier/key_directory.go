@@ -7,5 +7,9 @@ type KeyDirectory interface {
 	//
 	// It is not recommended to use the clientSpecifiedAlg, although this
 	// is provided to adhere to the RFC spec.
+	//
+	// If the key is not found, the error should wrap ErrKeyNotFound. If the key
+	// does not support clientSpecifiedAlg, the error should wrap ErrAlgorithmMismatch.
+	// Other errors are treated as internal errors.
 	GetKey(ctx context.Context, kid string, clientSpecifiedAlg string) (Algorithm, error)
 }
@@ -1,6 +1,9 @@
 package verifier
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type NonceStorage interface {
 	// Seen returns true if a nonce has been previously seen.
@@ -12,3 +15,60 @@ type NonceStorage interface {
 	// as seen when Seen() is called, to protect against replay attacks.
 	Seen(ctx context.Context, nonce string) (bool, error)
 }
+
+// NonceRecord contains the nonce of a signature being verified,
+// along with the signature parameters which are relevant to storing it.
+type NonceRecord struct {
+	// Nonce is the 'nonce' signature parameter.
+	Nonce string
+
+	// KeyID is the 'keyid' signature parameter.
+	// It can be used to scope nonces to a particular key.
+	KeyID string
+
+	// Tag is the 'tag' signature parameter.
+	Tag string
+
+	// Created is the 'created' signature parameter.
+	Created time.Time
+
+	// Expires is the 'expires' signature parameter.
+	// It is the zero time if the signature does not have an expiry.
+	Expires time.Time
+}
+
+// NonceRecordStorage is an optional interface which can be implemented
+// by NonceStorage implementations to receive the signature parameters
+// along with the nonce.
+//
+// If the NonceStorage used by the Verifier implements this interface,
+// SeenWithContext is called instead of Seen.
+type NonceRecordStorage interface {
+	// SeenWithContext returns true if the nonce in the record has been previously seen.
+	//
+	// When implementing this interface you MUST mark the nonce as seen
+	// when SeenWithContext() is called, to protect against replay attacks.
+	// Nonces should be stored until the signature would no longer pass
+	// validation, such as when the Created time falls outside of
+	// the ValidateOpts.BeforeDuration window.
+	SeenWithContext(ctx context.Context, record NonceRecord) (bool, error)
+}
+
+// AdaptNonceStorage returns a NonceRecordStorage for a NonceStorage.
+//
+// If the storage implements NonceRecordStorage it is returned as-is,
+// otherwise Seen is called with the nonce from the record.
+func AdaptNonceStorage(s NonceStorage) NonceRecordStorage {
+	if rs, ok := s.(NonceRecordStorage); ok {
+		return rs
+	}
+	return nonceStorageAdapter{s}
+}
+
+type nonceStorageAdapter struct {
+	storage NonceStorage
+}
+
+func (a nonceStorageAdapter) SeenWithContext(ctx context.Context, record NonceRecord) (bool, error) {
+	return a.storage.Seen(ctx, record.Nonce)
+}
@@ -0,0 +1,77 @@
+package verifier
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/google/go-cmp/cmp"
+)
+
+type testNonceRecordStorage struct {
+	testNonceStorage
+	records []NonceRecord
+}
+
+func (t *testNonceRecordStorage) SeenWithContext(ctx context.Context, record NonceRecord) (bool, error) {
+	t.records = append(t.records, record)
+	return false, nil
+}
+
+func TestVerifier_Parse_NonceRecordStorage(t *testing.T) {
+	// Seen returns true, so the request fails if Seen is
+	// called rather than SeenWithContext.
+	storage := &testNonceRecordStorage{
+		testNonceStorage: testNonceStorage{IsSeen: true},
+	}
+
+	v := Verifier{
+		NonceStorage: storage,
+		KeyDirectory: testAlgSelector{
+			Algorithm: testAlgorithm{
+				Digest:  contentdigest.SHA256,
+				AlgType: "ecdsa-p256-sha256",
+			},
+		},
+		Tag:       "example-app",
+		Authority: "example.com",
+		Scheme:    "https",
+	}
+
+	req, _ := http.NewRequest("GET", "https://example.com", nil)
+	req.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+	req.Header.Add("Signature-Input", `sig1=("@method" "@target-uri");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";nonce="abc";created=1704254706;expires=1704254766`)
+
+	_, _, err := v.Parse(nil, req, time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []NonceRecord{
+		{
+			Nonce:   "abc",
+			KeyID:   "testkey-123",
+			Tag:     "example-app",
+			Created: time.Unix(1704254706, 0),
+			Expires: time.Unix(1704254766, 0),
+		},
+	}
+
+	if diff := cmp.Diff(want, storage.records); diff != "" {
+		t.Errorf("records mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdaptNonceStorage(t *testing.T) {
+	adapted := AdaptNonceStorage(testNonceStorage{IsSeen: true})
+
+	seen, err := adapted.SeenWithContext(context.Background(), NonceRecord{Nonce: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !seen {
+		t.Fatal("expected the adapter to call Seen")
+	}
+}
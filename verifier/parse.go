@@ -1,13 +1,17 @@
 package verifier
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"slices"
 	"time"
 
+	"github.com/common-fate/httpsig/contentdigest"
 	"github.com/common-fate/httpsig/sigbase"
+	"github.com/common-fate/httpsig/signature"
+	"github.com/common-fate/httpsig/sigparams"
 	"github.com/common-fate/httpsig/sigset"
 )
 
@@ -20,14 +24,33 @@ import (
 //
 // See: https://www.rfc-editor.org/rfc/rfc9421.html#section-3.2
 //
-// This method returns a parsed http.Request with all non-covered headers removed.
+// This method returns a parsed http.Request with all non-covered headers and trailers removed.
 // The request body is also removed unless 'content-digest' and 'content-length'
-// are included in the covered components.
+// are included in the covered components, or the Content-Digest trailer is covered
+// using 'content-digest;tr'.
+//
+// If the request contains a Content-Digest or Repr-Digest header, the signature is
+// verified over the declared digest, and the request body is then verified against
+// the strongest algorithm in the header which is at least as strong as the algorithm
+// of the key's content digester.
+//
+// If v.StreamBody is true, the request body is verified as it is read
+// rather than being read into memory. If v.SpillThreshold is set, large
+// request bodies are written to a temporary file and req.Body is replaced,
+// so callers must close req.Body once the request has been handled,
+// even if verification fails.
+//
+// If v.Policies is set, the signatures required by the policies are verified, and
+// the headers covered by any of the verified signatures are included in the parsed
+// request. The returned Algorithm is the key of the first verified signature.
+//
+// If verification fails, the returned error is a *Error containing a machine-readable
+// Reason. Use errors.Is and errors.As to inspect the cause of the error.
 func (v *Verifier) Parse(w http.ResponseWriter, req *http.Request, now time.Time) (*http.Request, Algorithm, error) {
 	ctx := req.Context()
 
 	if req.Host != v.Authority {
-		return nil, nil, fmt.Errorf("request host %q was not equal to expected authority %q", req.Host, v.Authority)
+		return nil, nil, fail(ReasonInvalidAuthority, fmt.Errorf("%w: request host %q was not equal to expected authority %q", ErrAuthorityMismatch, req.Host, v.Authority))
 	}
 
 	// set the scheme and authority based on our expected settings,
@@ -49,28 +72,173 @@ func (v *Verifier) Parse(w http.ResponseWriter, req *http.Request, now time.Time
 	// otherwise the request is invalid.
 
 	set, err := sigset.Unmarshal(req)
+	if err != nil {
+		return nil, nil, fail(ReasonMalformedSignature, fmt.Errorf("%w: %w", ErrMalformedSignature, err))
+	}
+
+	err = v.includeTrailerSignatures(w, req, set)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	msg, err := set.Find(v.Tag)
+	// if the request declares the digest of the body, the
+	// signature is verified over the declared digest, and the
+	// body is verified against the digest afterwards.
+	declared := v.StreamBody || declaresDigest(req)
+
+	verified, err := v.verifyPolicies(ctx, set, now, func(params sigparams.Params, digester contentdigest.Digester) (*sigbase.Base, error) {
+		if declared {
+			return sigbase.DeriveWithDeclaredDigest(params, w, req, digester)
+		}
+		return sigbase.Derive(params, w, req, digester)
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("finding matching signature: %w", err)
+		return nil, nil, err
 	}
 
-	// Validate the signature params.
-	err = msg.Input.Validate(v.Validation, now)
+	// the key of the first verified signature is returned, and the body
+	// is verified using the key of the signature which covers the body.
+	key := verified[0].key
+	base, bodyKey := mergeVerified(verified)
+
+	r2 := new(http.Request)
+	*r2 = *req
+
+	// copy the covered HTTP headers and trailers to the cloned request
+	r2.Header = base.Header
+	r2.Trailer = base.Trailer
+
+	bodyIsCovered := base.BodyIsCovered()
+
+	if declared {
+		err = v.verifyDeclaredDigests(w, req, r2, base, bodyKey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// if the Content-Digest trailer is covered, the digest
+	// must be checked against the request body.
+	if digest := base.Trailer.Values("Content-Digest"); len(digest) > 0 {
+		digester, err := v.contentDigest(bodyKey).Negotiate(digest)
+		if err == nil {
+			err = digester.VerifyRequest(w, req, digest)
+		}
+		if err != nil {
+			return nil, nil, fail(bodyReason(err, ReasonInvalidComponent), fmt.Errorf("verifying Content-Digest trailer: %w", err))
+		}
+		r2.Body = req.Body
+		bodyIsCovered = true
+	}
+
+	if !bodyIsCovered {
+		if req.Body != nil {
+			err = req.Body.Close()
+			if err != nil {
+				return nil, nil, fmt.Errorf("error closing original request body because it is not covered by the HTTP signature: %w", err)
+			}
+		}
+		// strip the request body as it isn't signed, so we
+		// can't trust it.
+		r2.Body = UncoveredBody{}
+	}
+
+	return r2, key, nil
+}
+
+// deriveFunc recreates the signature base from the target message.
+type deriveFunc func(params sigparams.Params, digester contentdigest.Digester) (*sigbase.Base, error)
+
+// verifyMessage finds the signature matching the policy's tag in the set,
+// validates the signature params and verifies the signature.
+//
+// If p.Chain is set, the earlier signatures in the chain are also verified.
+func (v *Verifier) verifyMessage(ctx context.Context, p Policy, set *sigset.Set, now time.Time, derive deriveFunc) (*sigbase.Base, Algorithm, error) {
+	_, msg, err := findSignature(set, p.Tag)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Validate the nonce has not been seen before.
-	seen, err := v.NonceStorage.Seen(ctx, msg.Input.Nonce)
+	base, key, err := v.verifySignature(ctx, p, msg, now, derive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = v.verifyChain(ctx, p, set, msg, now, derive)
 	if err != nil {
 		return nil, nil, err
 	}
+
+	return base, key, nil
+}
+
+// findSignature finds the signature matching the tag in the set.
+func findSignature(set *sigset.Set, tag string) (string, *signature.Message, error) {
+	label, msg, err := set.FindWithLabel(tag)
+	if errors.As(err, new(MultipleSignaturesError)) {
+		return "", nil, fail(ReasonMultipleSignatures, fmt.Errorf("finding matching signature: %w", err))
+	}
+	if err != nil {
+		return "", nil, fail(ReasonSignatureNotFound, fmt.Errorf("%w: %w", ErrSignatureNotFound, err))
+	}
+	return label, msg, nil
+}
+
+// verifyChain verifies the signatures with the tags in p.Chain, starting
+// with the signature which is covered by msg and working backwards.
+//
+// Each signature in the chain must be covered by the signature after it,
+// using the 'signature' and 'signature-input' components with the 'key' parameter.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-4.3
+func (v *Verifier) verifyChain(ctx context.Context, p Policy, set *sigset.Set, msg *signature.Message, now time.Time, derive deriveFunc) error {
+	covering := msg
+
+	for i := len(p.Chain) - 1; i >= 0; i-- {
+		tag := p.Chain[i]
+
+		label, covered, err := findSignature(set, tag)
+		if err != nil {
+			return err
+		}
+
+		if !covering.Input.CoversSignature(label) {
+			return fail(ReasonSignatureNotCovered, fmt.Errorf("%w: signature %q with tag %q was not covered by the signature with tag %q", ErrSignatureNotCovered, label, tag, covering.Input.Tag))
+		}
+
+		_, _, err = v.verifySignature(ctx, p, covered, now, derive)
+		if err != nil {
+			return fail(ReasonOf(err), fmt.Errorf("verifying signature %q with tag %q in chain: %w", label, tag, err))
+		}
+
+		covering = covered
+	}
+
+	return nil
+}
+
+// verifySignature validates the signature params and verifies the signature,
+// using the key directory and validation options of the policy.
+func (v *Verifier) verifySignature(ctx context.Context, p Policy, msg *signature.Message, now time.Time, derive deriveFunc) (*sigbase.Base, Algorithm, error) {
+	// Validate the signature params.
+	err := msg.Input.Validate(*p.Validation, now)
+	if err != nil {
+		return nil, nil, fail(validationReason(err), err)
+	}
+
+	// Validate the nonce has not been seen before.
+	seen, err := AdaptNonceStorage(v.NonceStorage).SeenWithContext(ctx, NonceRecord{
+		Nonce:   msg.Input.Nonce,
+		KeyID:   msg.Input.KeyID,
+		Tag:     msg.Input.Tag,
+		Created: msg.Input.Created,
+		Expires: msg.Input.Expires,
+	})
+	if err != nil {
+		return nil, nil, fail(ReasonInternal, fmt.Errorf("checking nonce: %w", err))
+	}
 	if seen {
-		return nil, nil, errors.New("nonce has already been seen")
+		return nil, nil, fail(ReasonNonceReplayed, ErrNonceReplayed)
 	}
 
 	// Find the key associated with the Key ID.
@@ -86,16 +254,27 @@ func (v *Verifier) Parse(w http.ResponseWriter, req *http.Request, now time.Time
 	//
 	// 6.4. If the algorithm is explicitly stated in the signature parameters using a value
 	// from the "HTTP Signature Algorithms" registry, the verifier will use the referenced algorithm.
-	key, err := v.KeyDirectory.GetKey(ctx, msg.Input.KeyID, msg.Input.Alg)
+	key, err := p.KeyDirectory.GetKey(ctx, msg.Input.KeyID, msg.Input.Alg)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, nil, fail(ReasonKeyNotFound, err)
+	}
+	if errors.Is(err, ErrAlgorithmMismatch) {
+		return nil, nil, fail(ReasonAlgorithmMismatch, err)
+	}
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fail(ReasonInternal, fmt.Errorf("looking up key: %w", err))
 	}
 
 	// 6.5. If the algorithm is specified in more than one location (e.g., a combination of static
 	// configuration, the algorithm signature parameter, and the key material itself), the resolved
 	// algorithms MUST be the same. If the algorithms are not the same, the verifier MUST fail the verification.
 	if msg.Input.Alg != "" && msg.Input.Alg != key.Type() {
-		return nil, nil, fmt.Errorf("invalid algorithm signature parameter: wanted %q but got %q", key.Type(), msg.Input.Alg)
+		return nil, nil, fail(ReasonAlgorithmMismatch, fmt.Errorf("%w: wanted %q but got %q", ErrAlgorithmMismatch, key.Type(), msg.Input.Alg))
+	}
+
+	// the resolved algorithm must be in the set of allowable algorithms.
+	if len(v.AllowedAlgorithms) > 0 && !slices.Contains(v.AllowedAlgorithms, key.Type()) {
+		return nil, nil, fail(ReasonAlgorithmNotAllowed, fmt.Errorf("%w: %q", ErrAlgorithmNotAllowed, key.Type()))
 	}
 
 	// Use the received HTTP message and the parsed signature parameters to recreate the
@@ -104,43 +283,25 @@ func (v *Verifier) Parse(w http.ResponseWriter, req *http.Request, now time.Time
 	// for this signature serialized according to the rules described in Section 2.3.
 	//
 	// Note that this does not include the signature's label from the Signature-Input field.
-	base, err := sigbase.Derive(msg.Input, w, req, key.ContentDigest())
+	base, err := derive(msg.Input, v.contentDigest(key))
 	if err != nil {
-		return nil, nil, fmt.Errorf("recreating signature base: %w", err)
+		return nil, nil, fail(bodyReason(err, ReasonInvalidComponent), fmt.Errorf("recreating signature base: %w", err))
 	}
 
 	stringToSign, err := base.CanonicalString(msg.Input)
 	if err != nil {
-		return nil, nil, fmt.Errorf("recreating string to sign: %w", err)
+		return nil, nil, fail(ReasonInvalidComponent, fmt.Errorf("recreating string to sign: %w", err))
 	}
 
 	if v.OnDeriveSigningString != nil {
-		v.OnDeriveSigningString(req.Context(), stringToSign)
+		v.OnDeriveSigningString(ctx, stringToSign)
 	}
 
 	// Verify the signature using the provided algorithm.
 	err = key.Verify(ctx, stringToSign, msg.Signature)
 	if err != nil {
-		return nil, nil, fmt.Errorf("verifying signature: %w", err)
+		return nil, nil, fail(ReasonInvalidSignature, fmt.Errorf("%w: %w", ErrInvalidSignature, err))
 	}
 
-	r2 := new(http.Request)
-	*r2 = *req
-
-	// copy the covered HTTP headers to the cloned request
-	r2.Header = base.Header
-
-	if !base.BodyIsCovered() {
-		if req.Body != nil {
-			err = req.Body.Close()
-			if err != nil {
-				return nil, nil, fmt.Errorf("error closing original request body because it is not covered by the HTTP signature: %w", err)
-			}
-		}
-		// strip the request body as it isn't signed, so we
-		// can't trust it.
-		r2.Body = io.NopCloser(UncoveredBody{})
-	}
-
-	return r2, key, nil
+	return base, key, nil
 }
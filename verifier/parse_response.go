@@ -0,0 +1,89 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigbase"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+)
+
+// ParseResponse verifies the message signature on a HTTP response,
+// following the same process as Parse.
+//
+// The Scheme and Authority fields of the verifier are not used
+// when verifying responses.
+//
+// This method returns a parsed http.Response with all non-covered headers removed.
+// The response body is also removed unless 'content-digest' and 'content-length'
+// are included in the covered components.
+//
+// If the response contains a Content-Digest header, the signature is verified over
+// the declared digest, and the response body is then verified against the strongest
+// algorithm in the header which is at least as strong as the algorithm of the key's
+// content digester.
+func (v *Verifier) ParseResponse(res *http.Response, now time.Time) (*http.Response, Algorithm, error) {
+	ctx := context.Background()
+	if res.Request != nil {
+		ctx = res.Request.Context()
+	}
+
+	set, err := sigset.UnmarshalHeader(res.Header)
+	if err != nil {
+		return nil, nil, fail(ReasonMalformedSignature, fmt.Errorf("%w: %w", ErrMalformedSignature, err))
+	}
+
+	// if the response declares the digest of the body, the
+	// signature is verified over the declared digest, and the
+	// body is verified against the digest afterwards.
+	declared := len(res.Header.Values("Content-Digest")) > 0
+
+	verified, err := v.verifyPolicies(ctx, set, now, func(params sigparams.Params, digester contentdigest.Digester) (*sigbase.Base, error) {
+		if declared {
+			return sigbase.DeriveResponseWithDeclaredDigest(params, res, digester)
+		}
+		return sigbase.DeriveResponse(params, res, digester)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := verified[0].key
+	base, bodyKey := mergeVerified(verified)
+
+	if _, ok := base.Values["content-digest"]; ok && declared {
+		digest := res.Header.Values("Content-Digest")
+
+		digester, err := v.contentDigest(bodyKey).Negotiate(digest)
+		if err == nil {
+			err = digester.VerifyResponse(res, digest)
+		}
+		if err != nil {
+			return nil, nil, fail(bodyReason(err, ReasonInvalidComponent), fmt.Errorf("verifying Content-Digest header: %w", err))
+		}
+	}
+
+	res2 := new(http.Response)
+	*res2 = *res
+
+	// copy the covered HTTP headers to the cloned response
+	res2.Header = base.Header
+
+	if !base.BodyIsCovered() {
+		if res.Body != nil {
+			err = res.Body.Close()
+			if err != nil {
+				return nil, nil, fmt.Errorf("error closing original response body because it is not covered by the HTTP signature: %w", err)
+			}
+		}
+		// strip the response body as it isn't signed, so we
+		// can't trust it.
+		res2.Body = UncoveredBody{}
+	}
+
+	return res2, key, nil
+}
@@ -0,0 +1,240 @@
+package verifier
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVerifier_ParseResponse(t *testing.T) {
+	type fields struct {
+		NonceStorage NonceStorage
+		KeyDirectory KeyDirectory
+		Tag          string
+		Validation   sigparams.ValidateOpts
+	}
+	tests := []struct {
+		name            string
+		fields          fields
+		res             func() *http.Response
+		now             time.Time
+		wantHeaders     http.Header
+		wantBodyReadErr bool
+		wantBody        string
+		wantErr         bool
+	}{
+		{
+			name: "ok",
+			fields: fields{
+				NonceStorage: testNonceStorage{},
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Digest:  contentdigest.SHA256,
+						AlgType: "ecdsa-p256-sha256",
+					},
+				},
+				Tag: "example-app",
+				Validation: sigparams.ValidateOpts{
+					RequiredCoveredComponents: map[string]bool{
+						"@status": true,
+					},
+					BeforeDuration: time.Minute,
+				},
+			},
+			now: time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			res: func() *http.Response {
+				res := &http.Response{
+					StatusCode:    200,
+					Header:        http.Header{},
+					Body:          io.NopCloser(strings.NewReader("example body")),
+					ContentLength: 12,
+				}
+				res.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+				res.Header.Add("Signature-Input", `sig1=("@status" "content-digest" "content-length");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";created=1704254706`)
+				return res
+			},
+			wantBody:    "example body",
+			wantHeaders: http.Header{},
+		},
+		{
+			name: "declared_digest_with_different_algorithm",
+			fields: fields{
+				NonceStorage: testNonceStorage{},
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Digest:  contentdigest.SHA256,
+						AlgType: "ecdsa-p256-sha256",
+					},
+				},
+				Tag: "example-app",
+			},
+			now: time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			res: func() *http.Response {
+				res := &http.Response{
+					StatusCode:    200,
+					Header:        http.Header{},
+					Body:          io.NopCloser(strings.NewReader("example body")),
+					ContentLength: 12,
+				}
+				res.Header.Add("Content-Digest", `sha-512=:bqVkZ3nORPIGFPC5Dynow1hDDvTzU/Ys6Q8PQzO3byf/0X2J/phKk7yGykTX7gKnFyZKBeOdRYj8/DpSRnkiCw==:`)
+				res.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+				res.Header.Add("Signature-Input", `sig1=("@status" "content-digest" "content-length");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";created=1704254706`)
+				return res
+			},
+			wantBody:    "example body",
+			wantHeaders: http.Header{},
+		},
+		{
+			name: "fails_if_declared_digest_does_not_match_body",
+			fields: fields{
+				NonceStorage: testNonceStorage{},
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Digest:  contentdigest.SHA256,
+						AlgType: "ecdsa-p256-sha256",
+					},
+				},
+				Tag: "example-app",
+			},
+			now: time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			res: func() *http.Response {
+				res := &http.Response{
+					StatusCode:    200,
+					Header:        http.Header{},
+					Body:          io.NopCloser(strings.NewReader("tampered body")),
+					ContentLength: 13,
+				}
+				res.Header.Add("Content-Digest", `sha-512=:bqVkZ3nORPIGFPC5Dynow1hDDvTzU/Ys6Q8PQzO3byf/0X2J/phKk7yGykTX7gKnFyZKBeOdRYj8/DpSRnkiCw==:`)
+				res.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+				res.Header.Add("Signature-Input", `sig1=("@status" "content-digest" "content-length");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";created=1704254706`)
+				return res
+			},
+			wantErr: true,
+		},
+		{
+			name: "uncovered_body_and_headers_are_removed",
+			fields: fields{
+				NonceStorage: testNonceStorage{},
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Digest:  contentdigest.SHA256,
+						AlgType: "ecdsa-p256-sha256",
+					},
+				},
+				Tag: "example-app",
+			},
+			now: time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			res: func() *http.Response {
+				res := &http.Response{
+					StatusCode: 200,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader("example body")),
+				}
+				res.Header.Add("Content-Type", "application/json")
+				res.Header.Add("X-Uncovered", "foo")
+				res.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+				res.Header.Add("Signature-Input", `sig1=("@status" "content-type");keyid="testkey-123";alg="ecdsa-p256-sha256";tag="example-app";created=1704254706`)
+				return res
+			},
+			wantBodyReadErr: true,
+			wantHeaders: http.Header{
+				"Content-Type": {"application/json"},
+			},
+		},
+		{
+			name: "fails_if_nonce_is_seen",
+			fields: fields{
+				NonceStorage: testNonceStorage{IsSeen: true},
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{Digest: contentdigest.SHA256},
+				},
+				Tag: "example-app",
+			},
+			now: time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			res: func() *http.Response {
+				res := &http.Response{StatusCode: 200, Header: http.Header{}}
+				res.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+				res.Header.Add("Signature-Input", `sig1=("@status");keyid="testkey-123";tag="example-app";created=1704254706`)
+				return res
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails_if_alg_verification_error",
+			fields: fields{
+				NonceStorage: testNonceStorage{},
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{
+						Err:    errors.New("verification error"),
+						Digest: contentdigest.SHA256,
+					},
+				},
+				Tag: "example-app",
+			},
+			now: time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			res: func() *http.Response {
+				res := &http.Response{StatusCode: 200, Header: http.Header{}}
+				res.Header.Add("Signature", `sig1=:TU9DS19TSUdOQVRVUkU=:`)
+				res.Header.Add("Signature-Input", `sig1=("@status");keyid="testkey-123";tag="example-app";created=1704254706`)
+				return res
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails_if_unsigned",
+			fields: fields{
+				NonceStorage: testNonceStorage{},
+				KeyDirectory: testAlgSelector{
+					Algorithm: testAlgorithm{Digest: contentdigest.SHA256},
+				},
+				Tag: "example-app",
+			},
+			now: time.Date(2024, 01, 03, 04, 05, 06, 00, time.UTC),
+			res: func() *http.Response {
+				return &http.Response{StatusCode: 200, Header: http.Header{}}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Verifier{
+				NonceStorage: tt.fields.NonceStorage,
+				KeyDirectory: tt.fields.KeyDirectory,
+				Tag:          tt.fields.Tag,
+				Validation:   tt.fields.Validation,
+			}
+			got, _, err := v.ParseResponse(tt.res(), tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verifier.ParseResponse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotBody []byte
+			var gotHeaders http.Header
+
+			if got != nil {
+				gotBody, err = io.ReadAll(got.Body)
+				if (err != nil) != tt.wantBodyReadErr {
+					t.Errorf("read body error = %v, wantBodyReadErr %v", err, tt.wantBodyReadErr)
+					return
+				}
+				gotHeaders = got.Header
+			}
+
+			if diff := cmp.Diff(tt.wantBody, string(gotBody)); diff != "" {
+				t.Errorf("read body mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantHeaders, gotHeaders); diff != "" {
+				t.Errorf("headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/common-fate/httpsig/sigbase"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/common-fate/httpsig/sigset"
+)
+
+// Policy is the requirements for verifying
+// signatures with a particular tag.
+type Policy struct {
+	// Tag is the tag of the signatures the policy applies to.
+	// Messages must have only one signature matching the tag.
+	Tag string
+
+	// KeyDirectory is the directory used to look up signing
+	// key material for signatures matching the tag.
+	//
+	// If nil, Verifier.KeyDirectory is used.
+	KeyDirectory KeyDirectory
+
+	// Validation is the options to use when validating the
+	// signature params, such as the required covered components.
+	//
+	// If nil, Verifier.Validation is used.
+	Validation *sigparams.ValidateOpts
+
+	// Chain, if set, is the tags of earlier signatures in a chain of
+	// signatures ending with the signature matching Tag.
+	// See Verifier.Chain.
+	Chain []string
+}
+
+// PolicyMatch determines how many of a verifier's policies must be satisfied.
+type PolicyMatch int
+
+const (
+	// MatchAll requires a valid signature for every policy.
+	MatchAll PolicyMatch = iota
+
+	// MatchAny requires a valid signature for at least one policy.
+	// Policies are tried in order, and the first valid signature is used.
+	MatchAny
+)
+
+func (m PolicyMatch) String() string {
+	switch m {
+	case MatchAll:
+		return "all"
+	case MatchAny:
+		return "any"
+	}
+	return fmt.Sprintf("PolicyMatch(%d)", int(m))
+}
+
+// verifiedMessage is a signature which has been verified.
+type verifiedMessage struct {
+	base *sigbase.Base
+	key  Algorithm
+}
+
+// policies returns the verifier's policies, using the verifier's key
+// directory and validation options for any policies which don't set them.
+//
+// If v.Policies is empty, a single policy is returned
+// based on the Tag and Chain fields of the verifier.
+func (v *Verifier) policies() []Policy {
+	if len(v.Policies) == 0 {
+		return []Policy{{
+			Tag:          v.Tag,
+			KeyDirectory: v.KeyDirectory,
+			Validation:   &v.Validation,
+			Chain:        v.Chain,
+		}}
+	}
+
+	policies := make([]Policy, len(v.Policies))
+
+	for i, p := range v.Policies {
+		if p.KeyDirectory == nil {
+			p.KeyDirectory = v.KeyDirectory
+		}
+		if p.Validation == nil {
+			p.Validation = &v.Validation
+		}
+		policies[i] = p
+	}
+
+	return policies
+}
+
+// verifyPolicies verifies the signatures in the set which are
+// required by the verifier's policies.
+//
+// The verified signatures are returned in the order of the policies.
+func (v *Verifier) verifyPolicies(ctx context.Context, set *sigset.Set, now time.Time, derive deriveFunc) ([]verifiedMessage, error) {
+	policies := v.policies()
+
+	if v.PolicyMatch != MatchAny {
+		verified := make([]verifiedMessage, len(policies))
+
+		for i, p := range policies {
+			base, key, err := v.verifyMessage(ctx, p, set, now, derive)
+			if err != nil {
+				return nil, err
+			}
+			verified[i] = verifiedMessage{base: base, key: key}
+		}
+
+		return verified, nil
+	}
+
+	var (
+		tags     []string
+		firstErr error
+	)
+
+	for _, p := range policies {
+		tags = append(tags, p.Tag)
+
+		// policies without a matching signature are skipped.
+		_, _, err := findSignature(set, p.Tag)
+		if ReasonOf(err) == ReasonSignatureNotFound {
+			continue
+		}
+
+		base, key, err := v.verifyMessage(ctx, p, set, now, derive)
+		if err == nil {
+			return []verifiedMessage{{base: base, key: key}}, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return nil, fail(ReasonSignatureNotFound, fmt.Errorf("%w: could not find a signature matching any of the tags %q", ErrSignatureNotFound, tags))
+}
+
+// mergeVerified merges the signature bases of the verified signatures,
+// so that the headers and trailers covered by any of the signatures are kept.
+//
+// The message body is verified using the first signature which covers
+// the content-digest or repr-digest components, so the values from its
+// signature base are used, and its key is returned.
+func mergeVerified(verified []verifiedMessage) (*sigbase.Base, Algorithm) {
+	if len(verified) == 1 {
+		return verified[0].base, verified[0].key
+	}
+
+	body := verified[0]
+	for _, m := range verified {
+		_, coversContentDigest := m.base.Values["content-digest"]
+		_, coversReprDigest := m.base.Values["repr-digest"]
+		if coversContentDigest || coversReprDigest {
+			body = m
+			break
+		}
+	}
+
+	merged := sigbase.New()
+	merged.Values = body.base.Values
+
+	for _, m := range verified {
+		mergeHeader(merged.Header, m.base.Header)
+
+		if m.base.Trailer != nil {
+			if merged.Trailer == nil {
+				merged.Trailer = http.Header{}
+			}
+			mergeHeader(merged.Trailer, m.base.Trailer)
+		}
+	}
+
+	return merged, body.key
+}
+
+// mergeHeader copies the fields in src which are not already in dst.
+func mergeHeader(dst http.Header, src http.Header) {
+	for k, v := range src {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package verifier
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigparams"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVerifier_Parse_Policies(t *testing.T) {
+	now := time.Unix(1704254706, 0)
+
+	validKey := testAlgSelector{
+		Algorithm: testAlgorithm{AlgType: "hmac-sha256", Digest: contentdigest.SHA256},
+	}
+	invalidKey := testAlgSelector{
+		Algorithm: testAlgorithm{AlgType: "hmac-sha256", Digest: contentdigest.SHA256, Err: errors.New("invalid signature")},
+	}
+
+	partner := Policy{
+		Tag:          "partner",
+		KeyDirectory: validKey,
+		Validation: &sigparams.ValidateOpts{
+			BeforeDuration:            time.Minute,
+			RequiredCoveredComponents: map[string]bool{"x-partner": true},
+		},
+	}
+
+	internal := Policy{
+		Tag:          "internal",
+		KeyDirectory: validKey,
+		Validation: &sigparams.ValidateOpts{
+			BeforeDuration: 10 * time.Second,
+		},
+	}
+
+	// the stale signature was created 30 seconds ago, so it is
+	// too old for the internal policy's freshness window.
+	partnerSig := `partner=("@method" "x-partner");created=1704254706;keyid="p";tag="partner"`
+	internalSig := `internal=("@method" "x-internal");created=1704254706;keyid="i";tag="internal"`
+	staleInternalSig := `internal=("@method" "x-internal");created=1704254676;keyid="i";tag="internal"`
+	partnerWithoutHeaderSig := `partner=("@method");created=1704254706;keyid="p";tag="partner"`
+
+	tests := []struct {
+		name        string
+		policies    []Policy
+		match       PolicyMatch
+		sigInputs   []string
+		wantHeaders http.Header
+		wantReason  Reason
+	}{
+		{
+			name:        "any_partner",
+			policies:    []Policy{partner, internal},
+			match:       MatchAny,
+			sigInputs:   []string{partnerSig},
+			wantHeaders: http.Header{"X-Partner": {"true"}},
+		},
+		{
+			name:        "any_internal",
+			policies:    []Policy{partner, internal},
+			match:       MatchAny,
+			sigInputs:   []string{internalSig},
+			wantHeaders: http.Header{"X-Internal": {"true"}},
+		},
+		{
+			name: "any_skips_invalid_signature",
+			policies: []Policy{
+				{Tag: "partner", KeyDirectory: invalidKey, Validation: partner.Validation},
+				internal,
+			},
+			match:       MatchAny,
+			sigInputs:   []string{partnerSig, internalSig},
+			wantHeaders: http.Header{"X-Internal": {"true"}},
+		},
+		{
+			name:       "any_not_found",
+			policies:   []Policy{partner, internal},
+			match:      MatchAny,
+			wantReason: ReasonSignatureNotFound,
+		},
+		{
+			name: "any_invalid",
+			policies: []Policy{
+				{Tag: "partner", KeyDirectory: invalidKey, Validation: partner.Validation},
+				internal,
+			},
+			match:      MatchAny,
+			sigInputs:  []string{partnerSig},
+			wantReason: ReasonInvalidSignature,
+		},
+		{
+			name:       "any_required_component_missing",
+			policies:   []Policy{partner, internal},
+			match:      MatchAny,
+			sigInputs:  []string{partnerWithoutHeaderSig},
+			wantReason: ReasonMissingComponent,
+		},
+		{
+			name:       "any_stale",
+			policies:   []Policy{partner, internal},
+			match:      MatchAny,
+			sigInputs:  []string{staleInternalSig},
+			wantReason: ReasonSignatureExpired,
+		},
+		{
+			name:      "all",
+			policies:  []Policy{partner, internal},
+			match:     MatchAll,
+			sigInputs: []string{partnerSig, internalSig},
+			wantHeaders: http.Header{
+				"X-Partner":  {"true"},
+				"X-Internal": {"true"},
+			},
+		},
+		{
+			name:       "all_missing_signature",
+			policies:   []Policy{partner, internal},
+			match:      MatchAll,
+			sigInputs:  []string{partnerSig},
+			wantReason: ReasonSignatureNotFound,
+		},
+		{
+			name:       "all_stale",
+			policies:   []Policy{partner, internal},
+			match:      MatchAll,
+			sigInputs:  []string{partnerSig, staleInternalSig},
+			wantReason: ReasonSignatureExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "https://example.com/webhook", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Partner", "true")
+			req.Header.Set("X-Internal", "true")
+			req.Header.Set("X-Unsigned", "true")
+
+			for _, input := range tt.sigInputs {
+				req.Header.Add("Signature-Input", input)
+				label, _, _ := strings.Cut(input, "=")
+				req.Header.Add("Signature", label+"=:YWJj:")
+			}
+
+			v := Verifier{
+				NonceStorage: testNonceStorage{},
+				Policies:     tt.policies,
+				PolicyMatch:  tt.match,
+				Scheme:       "https",
+				Authority:    "example.com",
+			}
+
+			got, _, err := v.Parse(nil, req, now)
+			if tt.wantReason != "" {
+				if ReasonOf(err) != tt.wantReason {
+					t.Fatalf("Verifier.Parse() err = %v, want reason %q", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verifier.Parse() err = %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantHeaders, got.Header); diff != "" {
+				t.Errorf("Verifier.Parse() headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package verifier
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/common-fate/httpsig/contentdigest"
+	"github.com/common-fate/httpsig/sigset"
+)
+
+// includeTrailerSignatures adds any signatures sent in the trailers of
+// the request to the signature set.
+//
+// Trailers are only available once the request body has been read, so
+// if the request declares a Signature trailer the body is read before the
+// signature is verified, even if v.StreamBody is true. The body is limited
+// to v.MaxBodyBytes, or contentdigest.DefaultMaxBytes if it is not set, and
+// is written to a temporary file if it is larger than v.SpillThreshold.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.1.4
+func (v *Verifier) includeTrailerSignatures(w http.ResponseWriter, req *http.Request, set *sigset.Set) error {
+	if _, ok := req.Trailer["Signature"]; !ok {
+		return nil
+	}
+
+	// the signing key isn't known until the trailers have been read,
+	// so the body is buffered using the default digester. The request
+	// body is replaced with the buffered copy.
+	_, err := v.bodyDigester(contentdigest.SHA256).HashRequest(w, req)
+	if err != nil {
+		return fail(bodyReason(err, ReasonInternal), fmt.Errorf("reading request body to obtain trailers: %w", err))
+	}
+
+	trailerSet, err := sigset.UnmarshalHeader(req.Trailer)
+	if err != nil {
+		return fail(ReasonMalformedSignature, fmt.Errorf("%w: parsing trailer signatures: %w", ErrMalformedSignature, err))
+	}
+
+	for _, label := range trailerSet.Labels() {
+		// labels must be unique within a HTTP message.
+		err = set.AddWithLabel(label, trailerSet.Get(label))
+		if err != nil {
+			return fail(ReasonMalformedSignature, fmt.Errorf("%w: signature label %q was used in both the HTTP header and trailer", ErrMalformedSignature, label))
+		}
+	}
+
+	return nil
+}
@@ -3,13 +3,24 @@ package verifier
 import (
 	"context"
 
+	"github.com/common-fate/httpsig/contentdigest"
 	"github.com/common-fate/httpsig/sigparams"
 )
 
 // Verifier verifies message signatures on an incoming HTTP request.
+//
+// If a request contains a Content-Digest or Repr-Digest header, or StreamBody
+// is set, the verifier switches to verifying the declared digest: the signature
+// is verified over the header value, and the body is then checked against the
+// header using the strongest declared algorithm which is at least as strong as
+// the algorithm of the key's content digester. Otherwise, the body is hashed
+// using the key's content digester to derive the covered 'content-digest' value.
 type Verifier struct {
 	// NonceStorage is the storage layer
 	// to check whether a nonce has been previously seen.
+	//
+	// If NonceStorage implements NonceRecordStorage, it is
+	// called with the signature parameters along with the nonce.
 	NonceStorage NonceStorage
 
 	// KeyDirectory is the directory used to look up
@@ -20,14 +31,43 @@ type Verifier struct {
 	// This value is used by applications to help identify signatures relevant for specific applications or protocols.
 	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-2.3-4.12
 	//
-	// In this verifier implementation a tag MUST be specified.
+	// In this verifier implementation a tag MUST be specified, unless Policies is set.
 	// incoming requests must have only one signature matching the tag.
 	Tag string
 
+	// Chain, if set, is the tags of earlier signatures in a chain of
+	// signatures, such as a client signature which has been countersigned
+	// by a proxy. Chain is ordered from the first signature in the chain.
+	//
+	// The signature matching Tag must cover the signature matching the last
+	// tag in Chain, which must cover the signature before it, and so on.
+	// A signature is covered using the 'signature' and 'signature-input'
+	// components with the 'key' parameter set to its label.
+	// Each signature in the chain is verified.
+	//
+	// Only the components covered by the signature matching Tag are
+	// included in the parsed request.
+	//
+	// See: https://www.rfc-editor.org/rfc/rfc9421.html#section-4.3
+	Chain []string
+
 	// Validation is the options to use when validating the
 	// signature params.
 	Validation sigparams.ValidateOpts
 
+	// Policies, if set, are the requirements for verifying signatures with
+	// different tags, each with its own key directory and validation options.
+	// Tag and Chain are ignored if Policies is set.
+	//
+	// This allows an endpoint to accept signatures from different sources,
+	// such as partner webhooks and internal services, or to require signatures
+	// from multiple sources, such as a client and a gateway.
+	Policies []Policy
+
+	// PolicyMatch determines whether all of the Policies must be
+	// satisfied, or any one of them. By default, all are required.
+	PolicyMatch PolicyMatch
+
 	// Scheme is the expected URL scheme
 	// that the verifier is running on.
 	//
@@ -38,6 +78,43 @@ type Verifier struct {
 	// that the verifier is running on.
 	Authority string
 
+	// AllowedAlgorithms, if set, are the signing algorithms which are
+	// accepted, such as 'ecdsa-p256-sha256'. The algorithm of the key
+	// returned by the key directory must be one of the allowed algorithms.
+	AllowedAlgorithms []string
+
+	// StreamBody, if true, verifies the signature over the declared
+	// Content-Digest header rather than reading the request body into memory.
+	//
+	// The returned request body hashes the body as it is read, and returns
+	// contentdigest.ErrDigestMismatch instead of io.EOF if the body does not
+	// match the declared digest. ContentDigest().MaxBytes is not applied.
+	//
+	// Handlers must read the body to EOF and check the error before acting on it.
+	//
+	// StreamBody does not apply to signatures sent in the request trailers,
+	// which can only be verified once the whole body has been read.
+	StreamBody bool
+
+	// SpillThreshold, if non-zero, is the number of bytes of the request body
+	// to hold in memory when verifying the Content-Digest. Larger bodies are
+	// written to a temporary file in TempDir, which is removed when the body
+	// of the parsed request is closed.
+	SpillThreshold int64
+
+	// TempDir is the directory to write temporary files to.
+	//
+	// If empty, the default directory for temporary files is used.
+	TempDir string
+
+	// MaxBodyBytes, if non-zero, is the maximum number of bytes of the
+	// request body to read when verifying the Content-Digest, overriding
+	// the MaxBytes of the key's content digester, which is 10MB by default.
+	//
+	// Set MaxBodyBytes along with SpillThreshold to accept
+	// request bodies which are too large to hold in memory.
+	MaxBodyBytes int64
+
 	// OnDeriveSigningString is a hook which can be used to log
 	// the string to sign.
 	//
@@ -46,3 +123,22 @@ type Verifier struct {
 	// and server.
 	OnDeriveSigningString func(ctx context.Context, stringToSign string)
 }
+
+// contentDigest returns the digester for a key, applying
+// the verifier's body size limit and buffering settings.
+func (v *Verifier) contentDigest(key Algorithm) contentdigest.Digester {
+	return v.bodyDigester(key.ContentDigest())
+}
+
+// bodyDigester applies the verifier's body size
+// limit and buffering settings to a digester.
+func (v *Verifier) bodyDigester(d contentdigest.Digester) contentdigest.Digester {
+	if v.MaxBodyBytes > 0 {
+		d.MaxBytes = v.MaxBodyBytes
+	}
+	if v.SpillThreshold > 0 {
+		d.SpillThreshold = v.SpillThreshold
+		d.TempDir = v.TempDir
+	}
+	return d
+}